@@ -0,0 +1,194 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// An AccessLogEntry describes a processed request.
+type AccessLogEntry struct {
+	Time       time.Time     // Time the request was received
+	Method     string        // Request method
+	Path       string        // Full request path including the query
+	Proto      string        // Request protocol, e.g. "HTTP/1.1"
+	Route      string        // Pattern of the matched Route, see RequestContext.RoutePattern
+	Status     int           // Response status code
+	Size       int           // Number of body bytes written
+	Latency    time.Duration // Time it took to process the request
+	RemoteAddr string        // Remote address of the client
+	RequestID  string        // Value of the request id header
+	User       string        // User name of the basic authentication or ""
+	Referer    string        // Referer request header
+	UserAgent  string        // User-Agent request header
+}
+
+// Attrs returns the entry as slog attributes.
+func (e *AccessLogEntry) Attrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String("method", e.Method),
+		slog.String("path", e.Path),
+		slog.String("proto", e.Proto),
+		slog.String("route", e.Route),
+		slog.Int("status", e.Status),
+		slog.Int("size", e.Size),
+		slog.Duration("latency", e.Latency),
+		slog.String("remote_addr", e.RemoteAddr),
+		slog.String("request_id", e.RequestID),
+		slog.String("user", e.User),
+		slog.String("referer", e.Referer),
+		slog.String("user_agent", e.UserAgent),
+	}
+}
+
+// An AccessLogFormat writes a single AccessLogEntry to w, including the
+// trailing newline.
+type AccessLogFormat func(w io.Writer, e *AccessLogEntry) error
+
+// CombinedLogFormat writes entries in the Apache Combined Log Format.
+func CombinedLogFormat(w io.Writer, e *AccessLogEntry) error {
+	host, _, err := net.SplitHostPort(e.RemoteAddr)
+	if err != nil {
+		host = e.RemoteAddr
+	}
+
+	size := "-"
+	if e.Size > 0 {
+		size = strconv.Itoa(e.Size)
+	}
+
+	_, err = fmt.Fprintf(w, "%s - %s [%s] \"%s %s %s\" %d %s %q %q\n",
+		orDash(host), orDash(e.User), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.Path, e.Proto, e.Status, size, orDash(e.Referer), orDash(e.UserAgent))
+
+	return err
+}
+
+// JSONLogFormat writes entries as JSON objects, one per line.
+// The latency is written in milliseconds.
+func JSONLogFormat(w io.Writer, e *AccessLogEntry) error {
+	return json.NewEncoder(w).Encode(struct {
+		Time       time.Time `json:"time"`
+		Method     string    `json:"method"`
+		Path       string    `json:"path"`
+		Proto      string    `json:"proto"`
+		Route      string    `json:"route,omitempty"`
+		Status     int       `json:"status"`
+		Size       int       `json:"size"`
+		Latency    float64   `json:"latency_ms"`
+		RemoteAddr string    `json:"remote_addr"`
+		RequestID  string    `json:"request_id,omitempty"`
+		User       string    `json:"user,omitempty"`
+		Referer    string    `json:"referer,omitempty"`
+		UserAgent  string    `json:"user_agent,omitempty"`
+	}{
+		e.Time, e.Method, e.Path, e.Proto, e.Route, e.Status, e.Size,
+		float64(e.Latency) / float64(time.Millisecond),
+		e.RemoteAddr, e.RequestID, e.User, e.Referer, e.UserAgent,
+	})
+}
+
+// AccessLogOptions configure the middleware returned by AccessLog.
+type AccessLogOptions struct {
+	// Output receives the formatted entries. Defaults to os.Stdout.
+	Output io.Writer
+
+	// Format of the entries written to Output. Defaults to CombinedLogFormat.
+	Format AccessLogFormat
+
+	// If set, entries are logged with the entry's attributes using Logger
+	// instead of being written to Output.
+	Logger *slog.Logger
+
+	// Request header containing the request id. Defaults to "X-Request-Id".
+	RequestIDHeader string
+}
+
+// AccessLog returns a new HandlerFunc logging all requests after they were
+// processed completely. It should be registered as the first middleware of
+// a Server to capture the full latency.
+//
+//	server.Use(goserv.AccessLog(goserv.AccessLogOptions{Format: goserv.JSONLogFormat}))
+func AccessLog(opts AccessLogOptions) http.HandlerFunc {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	if opts.Format == nil {
+		opts.Format = CombinedLogFormat
+	}
+
+	if len(opts.RequestIDHeader) == 0 {
+		opts.RequestIDHeader = "X-Request-Id"
+	}
+
+	var mutex sync.Mutex
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := Context(r)
+
+		ctx.Defer(func() {
+			res := w.(ResponseWriter)
+
+			// Nothing written means the http package sends a 200 OK.
+			status := res.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			user, _, _ := r.BasicAuth()
+
+			entry := &AccessLogEntry{
+				Time:       start,
+				Method:     r.Method,
+				Path:       r.URL.RequestURI(),
+				Proto:      r.Proto,
+				Route:      ctx.RoutePattern(),
+				Status:     status,
+				Size:       res.Size(),
+				Latency:    time.Since(start),
+				RemoteAddr: r.RemoteAddr,
+				RequestID:  r.Header.Get(opts.RequestIDHeader),
+				User:       user,
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+			}
+
+			if opts.Logger != nil {
+				opts.Logger.LogAttrs(r.Context(), slog.LevelInfo, "access", entry.Attrs()...)
+				return
+			}
+
+			// Format first to write each entry with a single call.
+			var buf bytes.Buffer
+			if err := opts.Format(&buf, entry); err != nil {
+				return
+			}
+
+			mutex.Lock()
+			opts.Output.Write(buf.Bytes())
+			mutex.Unlock()
+		})
+	}
+}
+
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+
+	return s
+}
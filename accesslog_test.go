@@ -0,0 +1,106 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func newAccessLogTestServer(opts AccessLogOptions) *Server {
+	server := NewServer()
+	server.Use(AccessLog(opts))
+	server.SubRouter("/api").Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		WriteString(w, "user")
+	})
+
+	return server
+}
+
+func newAccessLogTestRequest(path string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Request-Id", "abc")
+	r.Header.Set("User-Agent", "test-agent")
+	return r
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	var buf bytes.Buffer
+	server := newAccessLogTestServer(AccessLogOptions{Output: &buf})
+
+	server.ServeHTTP(httptest.NewRecorder(), newAccessLogTestRequest("/api/users/42?full=1"))
+	server.ServeHTTP(httptest.NewRecorder(), newAccessLogTestRequest("/missing"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Wrong line count: %d != 2, %q", len(lines), buf.String())
+	}
+
+	expected := []*regexp.Regexp{
+		regexp.MustCompile(`^10\.0\.0\.1 - - \[[^\]]+\] "GET /api/users/42\?full=1 HTTP/1\.1" 200 4 "-" "test-agent"$`),
+		regexp.MustCompile(`^10\.0\.0\.1 - - \[[^\]]+\] "GET /missing HTTP/1\.1" 404 9 "-" "test-agent"$`),
+	}
+
+	for index, rx := range expected {
+		if !rx.MatchString(lines[index]) {
+			t.Errorf("Wrong log line: %q", lines[index])
+		}
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	server := newAccessLogTestServer(AccessLogOptions{Output: &buf, Format: JSONLogFormat})
+
+	server.ServeHTTP(httptest.NewRecorder(), newAccessLogTestRequest("/api/users/42"))
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Invalid JSON: %v, %q", err, buf.String())
+	}
+
+	expected := map[string]interface{}{
+		"method":      "GET",
+		"path":        "/api/users/42",
+		"route":       "/api/users/:id",
+		"status":      float64(200),
+		"size":        float64(4),
+		"remote_addr": "10.0.0.1:1234",
+		"request_id":  "abc",
+		"user_agent":  "test-agent",
+	}
+
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Wrong value for %q: %v != %v", key, entry[key], value)
+		}
+	}
+
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Error("Missing latency")
+	}
+}
+
+func TestAccessLogSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	server := newAccessLogTestServer(AccessLogOptions{Logger: logger})
+
+	server.ServeHTTP(httptest.NewRecorder(), newAccessLogTestRequest("/api/users/42"))
+
+	line := buf.String()
+	for _, attr := range []string{"msg=access", "method=GET", "route=/api/users/:id", "status=200", "size=4", "request_id=abc"} {
+		if !strings.Contains(line, attr) {
+			t.Errorf("Missing attribute %q in %q", attr, line)
+		}
+	}
+}
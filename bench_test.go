@@ -5,6 +5,7 @@
 package goserv
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 )
@@ -56,3 +57,63 @@ func BenchmarkNestedRouterWithParams(b *testing.B) {
 		s.ServeHTTP(nil, req)
 	}
 }
+
+// Registers routes similar to those of a larger REST API
+// and returns a path to request.
+func registerManyRoutes(router *Router) string {
+	for i := 0; i < 100; i++ {
+		resource := fmt.Sprintf("/v1/resource%d", i)
+
+		router.Get(resource, DummyHandlerFunc)
+		router.Post(resource, DummyHandlerFunc)
+		router.Get(resource+"/:id", DummyHandlerFunc)
+		router.Put(resource+"/:id", DummyHandlerFunc)
+		router.Get(resource+"/:id/children/:child_id", DummyHandlerFunc)
+		router.Delete(resource+"/:id<int>", DummyHandlerFunc)
+		router.Get(resource+`/:id<int>/stats/:day(\d{4}-\d{2}-\d{2})`, DummyHandlerFunc)
+	}
+
+	return "/v1/resource99/123456/children/42"
+}
+
+func BenchmarkManyRoutesLinear(b *testing.B) {
+	router := newRouter()
+	path := registerManyRoutes(router)
+	params := make(params)
+
+	// Matches every route using its matcher, which was the
+	// dispatching strategy prior to the routeTree.
+	for i := 0; i < b.N; i++ {
+		for _, route := range router.routes {
+			if route.match(path) {
+				route.fillParams(path, params)
+			}
+		}
+	}
+}
+
+func BenchmarkManyRoutesTree(b *testing.B) {
+	router := newRouter()
+	path := registerManyRoutes(router)
+	params := make(params)
+
+	for i := 0; i < b.N; i++ {
+		segments, leaves := router.tree.Lookup(path)
+
+		for _, leaf := range leaves {
+			leaf.fillParams(segments, params)
+		}
+	}
+}
+
+func BenchmarkServerManyRoutes(b *testing.B) {
+	server := NewServer()
+	server.ErrorHandler = nil
+
+	path := registerManyRoutes(server.Router)
+
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i < b.N; i++ {
+		server.ServeHTTP(nil, req)
+	}
+}
@@ -0,0 +1,438 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnsupportedMediaType is wrapped by the BindError returned by Bind
+	// if the request body has an unsupported Content-Type.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// ErrBodyTooLarge is wrapped by the BindError returned by Bind if
+	// the request body exceeds the maximum size.
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+// A BindError describes why a request couldn't be bound.
+type BindError struct {
+	// Source of the invalid value: "body", "form", "path", "query" or "header".
+	Source string
+
+	// Name of the parameter, form field or header, "" for body errors.
+	Field string
+
+	Err error
+}
+
+// Error returns a description of the error including the source and field.
+func (b *BindError) Error() string {
+	if len(b.Field) == 0 {
+		return fmt.Sprintf("invalid %s: %s", b.Source, b.Err)
+	}
+
+	return fmt.Sprintf("invalid %s value %q: %s", b.Source, b.Field, b.Err)
+}
+
+// Unwrap returns the underlying error.
+func (b *BindError) Unwrap() error {
+	return b.Err
+}
+
+// ContextError returns a ContextError with an appropriate status code, i.e.
+// "415 Unsupported Media Type" for unsupported body formats, "413 Request Entity
+// Too Large" for too large bodies and "400 Bad Request" for all other errors.
+func (b *BindError) ContextError() *ContextError {
+	switch {
+	case errors.Is(b.Err, ErrUnsupportedMediaType):
+		return UnsupportedMediaType(b)
+	case errors.Is(b.Err, ErrBodyTooLarge):
+		return NewContextError(http.StatusRequestEntityTooLarge, b)
+	}
+
+	return BadRequest(b)
+}
+
+// ProblemMembers implements Problem and adds the source and field
+// to the problem details.
+func (b *BindError) ProblemMembers() map[string]interface{} {
+	members := map[string]interface{}{"source": b.Source}
+
+	if len(b.Field) > 0 {
+		members["field"] = b.Field
+	}
+
+	return members
+}
+
+// BindOptions configure BindWith.
+type BindOptions struct {
+	// Maximum number of body bytes read. Zero means no limit.
+	MaxBodySize int64
+
+	// Maximum number of bytes of multipart forms stored in memory,
+	// see http.Request.ParseMultipartForm.
+	MaxMemory int64
+
+	// Allows JSON bodies with fields not present in the destination.
+	AllowUnknownFields bool
+
+	// Validates the destination using Validate after binding.
+	Validate bool
+}
+
+// DefaultBindOptions are used by Bind.
+var DefaultBindOptions = BindOptions{
+	MaxBodySize: 10 << 20,
+	MaxMemory:   32 << 20,
+}
+
+// Bind is a shortcut for BindWith(r, dst, DefaultBindOptions).
+func Bind(r *http.Request, dst interface{}) error {
+	return BindWith(r, dst, DefaultBindOptions)
+}
+
+// BindWith populates the struct dst points to with values from the request.
+//
+// First the body is decoded depending on the Content-Type, which can be JSON, XML,
+// a url-encoded or a multipart form. Form values are assigned to fields tagged with
+// "form", files of multipart forms to fields of type *multipart.FileHeader or
+// []*multipart.FileHeader. Afterwards fields tagged with "path", "query" or "header"
+// are populated with the RequestContext's parameters, the query and headers:
+//
+//	type UpdateUser struct {
+//		ID    int    `path:"id"`
+//		Token string `header:"X-Token"`
+//		Name  string `json:"name" form:"name"`
+//		Page  int    `query:"page"`
+//	}
+//
+// Tagged fields can be strings, booleans, numbers, time.Duration, time.Time (RFC 3339),
+// types implementing encoding.TextUnmarshaler, pointers to them and slices of them,
+// which receive all values. Missing values leave the field untouched. Untagged
+// struct fields are populated recursively.
+//
+// If opts.Validate is set, the struct is finally validated using Validate. To validate
+// all bound requests enable it in DefaultBindOptions.
+//
+// Errors caused by the request are returned as *BindError or *ValidationError, other
+// errors indicate programming errors, e.g. invalid tags. Both error types provide a
+// ContextError method returning a ContextError with the appropriate status:
+//
+//	if err := goserv.Bind(r, &user); err != nil {
+//		var bindErr *goserv.BindError
+//		if errors.As(err, &bindErr) {
+//			goserv.Context(r).SetError(bindErr.ContextError())
+//		}
+//		...
+//	}
+func BindWith(r *http.Request, dst interface{}, opts BindOptions) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind destination must be a pointer to a struct, got %T", dst)
+	}
+
+	if err := bindBody(r, dst, opts); err != nil {
+		return err
+	}
+
+	err := bindFields(v.Elem(), func(field reflect.StructField) (string, string, []string) {
+		if name, ok := field.Tag.Lookup("path"); ok {
+			if ctx := Context(r); ctx != nil {
+				if value, ok := ctx.params[name]; ok {
+					return "path", name, []string{value}
+				}
+			}
+
+			return "path", name, nil
+		}
+
+		if name, ok := field.Tag.Lookup("query"); ok {
+			return "query", name, r.URL.Query()[name]
+		}
+
+		if name, ok := field.Tag.Lookup("header"); ok {
+			return "header", name, r.Header.Values(name)
+		}
+
+		if name, ok := field.Tag.Lookup("form"); ok {
+			return "form", name, r.PostForm[name]
+		}
+
+		return "", "", nil
+	}, r.MultipartForm)
+
+	if err != nil {
+		return err
+	}
+
+	if opts.Validate {
+		return Validate(dst)
+	}
+
+	return nil
+}
+
+// Decodes the request body depending on the Content-Type.
+func bindBody(r *http.Request, dst interface{}, opts BindOptions) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	if opts.MaxBodySize > 0 {
+		r.Body = &limitedBody{r.Body, opts.MaxBodySize}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return &BindError{Source: "body", Err: ErrUnsupportedMediaType}
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(r.Body)
+		if !opts.AllowUnknownFields {
+			decoder.DisallowUnknownFields()
+		}
+
+		err = decoder.Decode(dst)
+		if err == io.EOF {
+			err = nil
+		}
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		err = xml.NewDecoder(r.Body).Decode(dst)
+		if err == io.EOF {
+			err = nil
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		err = r.ParseForm()
+	case mediaType == "multipart/form-data":
+		err = r.ParseMultipartForm(opts.MaxMemory)
+	default:
+		err = ErrUnsupportedMediaType
+	}
+
+	if err != nil {
+		return &BindError{Source: "body", Err: err}
+	}
+
+	return nil
+}
+
+// A fieldSource returns the source, name and values of a struct field or
+// an empty source if the field has no binding tag.
+type fieldSource func(reflect.StructField) (source, name string, values []string)
+
+// Populates the fields of the struct v with the values returned by fn.
+func bindFields(v reflect.Value, fn fieldSource, form *multipart.Form) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		source, name, values := fn(field)
+
+		if len(source) == 0 {
+			if fv.Kind() == reflect.Struct && !isBindValue(fv) {
+				if err := bindFields(fv, fn, form); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if source == "form" && bindFiles(fv, form, name) {
+			continue
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setBindValue(fv, values); err != nil {
+			return &BindError{Source: source, Field: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Assigns the files uploaded with the form field name to fields of type
+// *multipart.FileHeader or []*multipart.FileHeader. Returns false for all
+// other fields.
+func bindFiles(v reflect.Value, form *multipart.Form, name string) bool {
+	var files []*multipart.FileHeader
+	if form != nil {
+		files = form.File[name]
+	}
+
+	switch v.Type() {
+	case fileHeaderType:
+		if len(files) > 0 {
+			v.Set(reflect.ValueOf(files[0]))
+		}
+	case fileHeaderSliceType:
+		if len(files) > 0 {
+			v.Set(reflect.ValueOf(files))
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Returns true if v is a struct, which is bound from a single value
+// instead of its fields.
+func isBindValue(v reflect.Value) bool {
+	return v.Type() == timeType || reflect.PointerTo(v.Type()).Implements(textUnmarshalerType)
+}
+
+// Sets v to the converted values. Slices receive all values, all other types,
+// including slices implementing encoding.TextUnmarshaler like net.IP, only the
+// first one.
+func setBindValue(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && !reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+
+		for i, value := range values {
+			if err := setBindValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setBindValue(ptr.Elem(), values); err != nil {
+			return err
+		}
+
+		v.Set(ptr)
+		return nil
+	}
+
+	value := values[0]
+
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
+
+// A limitedBody returns ErrBodyTooLarge after n bytes were read.
+type limitedBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Only fail if there is more data.
+		var b [1]byte
+		if n, _ := l.ReadCloser.Read(b[:]); n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+
+	n, err := l.ReadCloser.Read(p)
+	l.n -= int64(n)
+
+	return n, err
+}
@@ -0,0 +1,191 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindPagination struct {
+	Page  int      `query:"page"`
+	Sort  []string `query:"sort"`
+	Limit *uint    `query:"limit"`
+}
+
+type bindTarget struct {
+	ID      int           `path:"id" json:"-" xml:"-"`
+	Token   string        `header:"X-Token" json:"-" xml:"-"`
+	Name    string        `json:"name" xml:"name" form:"name"`
+	Age     int           `json:"age" xml:"age" form:"age"`
+	Since   time.Time     `query:"since" json:"-" xml:"-"`
+	Timeout time.Duration `query:"timeout" json:"-" xml:"-"`
+	IP      net.IP        `query:"ip" json:"-" xml:"-"`
+	Paging  bindPagination
+
+	Avatar *multipart.FileHeader   `form:"avatar" json:"-" xml:"-"`
+	Files  []*multipart.FileHeader `form:"files" json:"-" xml:"-"`
+}
+
+func TestBind(t *testing.T) {
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("name", "multi")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	limit := uint(5)
+
+	tests := []struct {
+		Path, ContentType, Body string
+		Expected                bindTarget
+		Err                     *BindError
+		Code                    int
+	}{
+		{
+			Path:        "/users/12?page=2&sort=name&sort=-age&limit=5&since=2016-05-12T10:00:00Z&timeout=1m&ip=10.0.0.1",
+			ContentType: "application/json",
+			Body:        `{"name":"json","age":30}`,
+			Expected: bindTarget{ID: 12, Token: "secret", Name: "json", Age: 30,
+				Since: time.Date(2016, 5, 12, 10, 0, 0, 0, time.UTC), Timeout: time.Minute,
+				IP: net.ParseIP("10.0.0.1"), Paging: bindPagination{Page: 2, Sort: []string{"name", "-age"}, Limit: &limit}},
+		},
+		{
+			Path:        "/users/12",
+			ContentType: "application/vnd.api+json; charset=utf-8",
+			Body:        `{"name":"json"}`,
+			Expected:    bindTarget{ID: 12, Token: "secret", Name: "json"},
+		},
+		{
+			Path:        "/users/12",
+			ContentType: "application/xml",
+			Body:        `<user><name>xml</name><age>40</age></user>`,
+			Expected:    bindTarget{ID: 12, Token: "secret", Name: "xml", Age: 40},
+		},
+		{
+			Path:        "/users/12",
+			ContentType: "application/x-www-form-urlencoded",
+			Body:        `name=form&age=50`,
+			Expected:    bindTarget{ID: 12, Token: "secret", Name: "form", Age: 50},
+		},
+		{
+			Path:     "/users/12",
+			Expected: bindTarget{ID: 12, Token: "secret"},
+		},
+
+		// NEGATIVE TESTS //
+		{
+			Path:        "/users/12",
+			ContentType: "text/plain",
+			Body:        "name",
+			Err:         &BindError{Source: "body", Err: ErrUnsupportedMediaType},
+			Code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			Path:        "/users/12",
+			ContentType: "application/json",
+			Body:        `{"unknown":1}`,
+			Err:         &BindError{Source: "body"},
+			Code:        http.StatusBadRequest,
+		},
+		{
+			Path:        "/users/12",
+			ContentType: "application/json",
+			Body:        `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			Err:         &BindError{Source: "body", Err: ErrBodyTooLarge},
+			Code:        http.StatusRequestEntityTooLarge,
+		},
+		{
+			Path: "/users/12?page=abc",
+			Err:  &BindError{Source: "query", Field: "page"},
+			Code: http.StatusBadRequest,
+		},
+		{
+			Path: "/users/12?ip=10.0.0",
+			Err:  &BindError{Source: "query", Field: "ip"},
+			Code: http.StatusBadRequest,
+		},
+		{
+			Path: "/users/abc",
+			Err:  &BindError{Source: "path", Field: "id"},
+			Code: http.StatusBadRequest,
+		},
+	}
+
+	for index, test := range tests {
+		var result bindTarget
+		var err error
+
+		server := NewServer()
+		server.Post("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+			err = BindWith(r, &result, BindOptions{MaxBodySize: 64})
+		})
+
+		req := httptest.NewRequest(http.MethodPost, test.Path, strings.NewReader(test.Body))
+		req.Header.Set("X-Token", "secret")
+		if len(test.ContentType) > 0 {
+			req.Header.Set("Content-Type", test.ContentType)
+		}
+
+		server.ServeHTTP(httptest.NewRecorder(), req)
+
+		if test.Err != nil {
+			var bindErr *BindError
+			if !errors.As(err, &bindErr) {
+				t.Errorf("Expected BindError, got %v (no. %d)", err, index)
+				continue
+			}
+
+			if bindErr.Source != test.Err.Source || bindErr.Field != test.Err.Field {
+				t.Errorf("Wrong BindError: %v (no. %d)", bindErr, index)
+			}
+
+			if test.Err.Err != nil && !errors.Is(err, test.Err.Err) {
+				t.Errorf("Wrong BindError cause: %v != %v (no. %d)", bindErr.Err, test.Err.Err, index)
+			}
+
+			if code := bindErr.ContextError().Code; code != test.Code {
+				t.Errorf("Wrong status code: %d != %d (no. %d)", code, test.Code, index)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v (no. %d)", err, index)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, test.Expected) {
+			t.Errorf("Wrong result: %+v != %+v (no. %d)", result, test.Expected, index)
+		}
+	}
+
+	// Multipart
+	var result bindTarget
+	req := httptest.NewRequest(http.MethodPost, "/", &multipartBody)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	if err := Bind(createRequestContext(req), &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Name != "multi" || result.Avatar == nil || result.Avatar.Filename != "avatar.png" {
+		t.Errorf("Wrong multipart result: %+v", result)
+	}
+
+	if err := Bind(req, result); err == nil {
+		t.Error("Expected error for non-pointer destination")
+	}
+}
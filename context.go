@@ -5,7 +5,10 @@
 package goserv
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"sort"
 	"sync"
 )
 
@@ -23,6 +26,24 @@ type RequestContext struct {
 	params params
 	err    *ContextError
 	skip   bool
+
+	// Constraints of the current parameters, see ParamValue.
+	constraints map[string]*ParamConstraint
+
+	// Values of unnamed wildcards, see Capture.
+	captures []string
+
+	errPassed  bool
+	errHandled bool
+
+	// Methods registered on routes which matched the request
+	// path, but not the request method.
+	allowed map[string]bool
+
+	pattern         string
+	forwardedPrefix string
+	renderer        Renderer
+	deferred        []func()
 }
 
 // Set sets the value for the specified the key. It replaces any existing values.
@@ -56,21 +77,94 @@ func (r *RequestContext) Exists(key string) bool {
 }
 
 // Param returns the capture URL parameter value for the given parameter name. The name is
-// the one specified in one of the routing functions without the leading ":".
+// the one specified in one of the routing functions without the leading ":", or the name
+// of a named wildcard without the leading "*".
 func (r *RequestContext) Param(name string) string {
 	return r.params[name]
 }
 
+// Capture returns the value captured by the unnamed wildcard at the given index,
+// e.g. Capture(0) returns "b" for the path "/a/b/c" and the Route "/a/*/c". Only the
+// last Route with unnamed wildcards provides captures. If index is out of range
+// "" is returned.
+func (r *RequestContext) Capture(index int) string {
+	if index < 0 || index >= len(r.captures) {
+		return ""
+	}
+
+	return r.captures[index]
+}
+
+// RoutePattern returns the full pattern, including the mount paths of all sub routers,
+// of the last Route which processed the request. Middleware and sub router mounts
+// are ignored. If no Route processed the request "" is returned.
+func (r *RequestContext) RoutePattern() string {
+	return r.pattern
+}
+
+// ForwardedPrefix returns the path prefix stripped from the request path by the
+// Routers' Mount methods, comparable to the "X-Forwarded-Prefix" header set by proxies.
+// It is "" outside of handlers registered with Mount.
+func (r *RequestContext) ForwardedPrefix() string {
+	return r.forwardedPrefix
+}
+
+// Defer registers a function which is invoked after the request was processed
+// completely, including the error handling. Functions are invoked in the reverse
+// order they were registered.
+func (r *RequestContext) Defer(fn func()) {
+	r.deferred = append(r.deferred, fn)
+}
+
 // Error sets a ContextError which will be passed to the next error handler and
 // forces all Routers and Routes to stop processing.
 //
 // Note: Calling Error from different threads can cause race conditions. Also
-// calling Error more than once causes a runtime panic!
+// calling Error more than once causes a runtime panic! Use .SetError or
+// .AppendError if an error may already exist.
 func (r *RequestContext) Error(err error, code int) {
 	if r.err != nil {
 		panic("RequestContext: called .Error() twice")
 	}
-	r.err = &ContextError{err, code}
+	r.err = &ContextError{Err: err, Code: code}
+}
+
+// SetError is like .Error, but takes a ContextError and replaces any existing error
+// instead of panicking.
+func (r *RequestContext) SetError(err *ContextError) {
+	r.err = err
+}
+
+// AppendError is like .SetError, but if an error already exists, err is appended to it
+// using errors.Join. The code, message and headers of the existing error are
+// retained, though the headers of err are added.
+func (r *RequestContext) AppendError(err *ContextError) {
+	if r.err == nil {
+		r.err = err
+		return
+	}
+
+	r.err.Err = errors.Join(r.err.Err, err.Err)
+
+	if err.Header != nil {
+		if r.err.Header == nil {
+			r.err.Header = make(http.Header)
+		}
+
+		err.ApplyHeaders(r.err.Header)
+	}
+}
+
+// Err returns the current ContextError or nil if there is none.
+func (r *RequestContext) Err() *ContextError {
+	return r.err
+}
+
+// PassError declines the current error from within an error handler. The error is passed
+// to the next matching error handler of the Router or, if there is none, to the
+// error handlers of the parent Router.
+func (r *RequestContext) PassError() {
+	r.errPassed = true
 }
 
 // SkipRouter tells the current router to end processing, which means that the
@@ -85,6 +179,65 @@ func (r *RequestContext) skipped() {
 	r.skip = false
 }
 
+// Invokes the error handler and returns true if it handled
+// the error, i.e. didn't call .PassError.
+func (r *RequestContext) invokeErrorHandler(fn ErrorHandlerFunc, res http.ResponseWriter, req *http.Request) bool {
+	r.errPassed = false
+	fn(res, req, r.err)
+
+	if r.errPassed {
+		r.errPassed = false
+		return false
+	}
+
+	r.errHandled = true
+	return true
+}
+
+// Stores the constraints of the Route's parameters, replacing those of
+// parameters with the same name captured by previous Routes.
+func (r *RequestContext) setConstraints(route *Route) {
+	for _, name := range route.params() {
+		constraint := route.path.constraints[name]
+
+		if constraint == nil && r.constraints == nil {
+			continue
+		}
+
+		if r.constraints == nil {
+			r.constraints = make(map[string]*ParamConstraint)
+		}
+
+		r.constraints[name] = constraint
+	}
+}
+
+func (r *RequestContext) finish() {
+	for i := len(r.deferred) - 1; i >= 0; i-- {
+		r.deferred[i]()
+	}
+}
+
+func (r *RequestContext) allowMethods(methods []string) {
+	if r.allowed == nil {
+		r.allowed = make(map[string]bool)
+	}
+
+	for _, method := range methods {
+		r.allowed[method] = true
+	}
+}
+
+func (r *RequestContext) allowedMethods() []string {
+	methods := make([]string, 0, len(r.allowed))
+	for method := range r.allowed {
+		methods = append(methods, method)
+	}
+
+	sort.Strings(methods)
+	return methods
+}
+
 func newRequestContext() *RequestContext {
 	return &RequestContext{
 		store:  make(anyMap),
@@ -94,30 +247,27 @@ func newRequestContext() *RequestContext {
 	}
 }
 
-// Stores a RequestContext for each Request.
-var contextMutex sync.RWMutex
-var requestContextMap = make(map[*http.Request]*RequestContext)
+// contextKey is the type of the key under which the RequestContext is stored
+// in the Request's context.Context. Using an unexported type prevents
+// collisions with keys defined in other packages.
+type contextKey int
+
+const requestContextKey contextKey = 0
 
 // Context returns the corresponding RequestContext for the given Request.
+//
+// The RequestContext is carried by the Request's context.Context, so it is
+// still available on requests derived with .WithContext. Context returns nil
+// if the Request was not dispatched by a Server.
 func Context(r *http.Request) *RequestContext {
-	contextMutex.RLock()
-	defer contextMutex.RUnlock()
-	return requestContextMap[r]
-}
-
-// Stores a new RequestContext for the specified Request in the requestContextMap.
-// This may overwrite an existing RequestContext!
-func createRequestContext(r *http.Request) {
-	contextMutex.Lock()
-	requestContextMap[r] = newRequestContext()
-	contextMutex.Unlock()
+	ctx, _ := r.Context().Value(requestContextKey).(*RequestContext)
+	return ctx
 }
 
-// Removes the RequestContext for the given Request from the requestContextMap.
-func deleteRequestContext(r *http.Request) {
-	contextMutex.Lock()
-	delete(requestContextMap, r)
-	contextMutex.Unlock()
+// Returns a shallow copy of the Request carrying a new RequestContext.
+// This replaces any existing RequestContext!
+func createRequestContext(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestContextKey, newRequestContext()))
 }
 
 type params map[string]string
@@ -0,0 +1,194 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions configure the middleware returned by CORS.
+type CORSOptions struct {
+	// Origins allowed to make cross-origin requests, e.g. "https://www.example.com".
+	//
+	// Values starting with a period (e.g. ".example.com") allow all origins
+	// whose host is example.com or a subdomain of it, regardless of the scheme
+	// and port. A single "*" allows all origins.
+	AllowedOrigins []string
+
+	// If set, origins not listed in AllowedOrigins are allowed if
+	// AllowOriginFunc returns true.
+	AllowOriginFunc func(origin string) bool
+
+	// Methods allowed for cross-origin requests.
+	// Defaults to "GET", "HEAD" and "POST".
+	AllowedMethods []string
+
+	// Request headers allowed for cross-origin requests. A single "*" allows
+	// all headers. Defaults to "Accept", "Content-Type" and "X-Requested-With".
+	AllowedHeaders []string
+
+	// Response headers exposed to the client.
+	ExposedHeaders []string
+
+	// Allows requests to include credentials like cookies.
+	AllowCredentials bool
+
+	// Number of seconds the result of a preflight request can be cached.
+	// Zero omits the Access-Control-Max-Age header.
+	MaxAge int
+}
+
+// CORS returns a new HandlerFunc implementing Cross-Origin Resource Sharing.
+//
+// Preflight requests are answered immediately with "204 No Content", which ends the
+// processing before the request is dispatched to any Route. Therefore CORS must be
+// registered as middleware before all Routes, e.g.
+//
+//	server.Use(goserv.CORS(goserv.CORSOptions{AllowedOrigins: []string{".example.com"}}))
+func CORS(opts CORSOptions) http.HandlerFunc {
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = []string{"Accept", "Content-Type", "X-Requested-With"}
+	}
+
+	allowAllOrigins := len(opts.AllowedOrigins) == 1 && opts.AllowedOrigins[0] == "*"
+	allowAllHeaders := len(opts.AllowedHeaders) == 1 && opts.AllowedHeaders[0] == "*"
+
+	allowedHeaders := make(map[string]bool)
+	for _, header := range opts.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+
+	originAllowed := func(origin string) bool {
+		if allowAllOrigins {
+			return true
+		}
+
+		for _, allowedOrigin := range opts.AllowedOrigins {
+			if strings.HasPrefix(allowedOrigin, ".") && matchesDomain(origin, allowedOrigin) {
+				return true
+			}
+
+			if origin == allowedOrigin {
+				return true
+			}
+		}
+
+		return opts.AllowOriginFunc != nil && opts.AllowOriginFunc(origin)
+	}
+
+	methodAllowed := func(method string) bool {
+		for _, allowedMethod := range opts.AllowedMethods {
+			if method == allowedMethod {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	headersAllowed := func(headers []string) bool {
+		if allowAllHeaders {
+			return true
+		}
+
+		for _, header := range headers {
+			if !allowedHeaders[http.CanonicalHeaderKey(header)] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	setOrigin := func(h http.Header, origin string) {
+		if allowAllOrigins && !opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0
+
+		if !preflight {
+			h.Add("Vary", "Origin")
+
+			if len(origin) == 0 || !originAllowed(origin) {
+				return
+			}
+
+			setOrigin(h, origin)
+
+			if len(opts.ExposedHeaders) > 0 {
+				h.Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+			}
+
+			return
+		}
+
+		h.Add("Vary", "Origin")
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		headers := splitHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+
+		// Preflight requests are always answered here, but the CORS headers
+		// are only set if the request is allowed.
+		if len(origin) > 0 && originAllowed(origin) && methodAllowed(method) && headersAllowed(headers) {
+			setOrigin(h, origin)
+			h.Set("Access-Control-Allow-Methods", strings.Join(opts.AllowedMethods, ", "))
+
+			if len(headers) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			}
+
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(opts.MaxAge))
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Returns true if the host of origin is domain (without the leading period)
+// or a subdomain of it.
+func matchesDomain(origin, domain string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	return host == domain[1:] || strings.HasSuffix(host, domain)
+}
+
+// Splits a comma separated header value and trims all values.
+func splitHeaderList(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	h := newHistoryHandler()
+
+	server := NewServer()
+	server.Use(CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://www.example.com", ".example.org"},
+		AllowOriginFunc:  func(origin string) bool { return strings.HasSuffix(origin, ".local") },
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders:   []string{"Content-Type", "X-Token"},
+		ExposedHeaders:   []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}))
+	server.Get("/", h.WriteHandler("get-handler"))
+	server.Route("/").Method(http.MethodOptions, h.WriteHandler("options-handler"))
+
+	tests := []struct {
+		method    string
+		origin    string
+		reqMethod string
+		reqHdrs   string
+		code      int
+		headers   map[string]string
+		writes    []string
+	}{
+		// Actual requests
+		{http.MethodGet, "https://www.example.com", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin":      "https://www.example.com",
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Expose-Headers":    "X-Total",
+			"Vary":                             "Origin",
+		}, []string{"get-handler"}},
+		{http.MethodGet, "https://api.example.org:8080", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin": "https://api.example.org:8080",
+		}, []string{"get-handler"}},
+		{http.MethodGet, "http://example.org", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin": "http://example.org",
+		}, []string{"get-handler"}},
+		{http.MethodGet, "http://dev.local", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin": "http://dev.local",
+		}, []string{"get-handler"}},
+		{http.MethodGet, "https://evil.com", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin": "",
+			"Vary":                        "Origin",
+		}, []string{"get-handler"}},
+		{http.MethodGet, "https://notexample.org", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, []string{"get-handler"}},
+
+		// Preflight requests
+		{http.MethodOptions, "https://www.example.com", http.MethodPut, "content-type, x-token", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin":      "https://www.example.com",
+			"Access-Control-Allow-Methods":     "GET, PUT",
+			"Access-Control-Allow-Headers":     "content-type, x-token",
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Max-Age":           "600",
+		}, nil},
+		{http.MethodOptions, "https://www.example.com", http.MethodDelete, "", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, nil},
+		{http.MethodOptions, "https://www.example.com", http.MethodGet, "X-Other", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, nil},
+		{http.MethodOptions, "https://evil.com", http.MethodGet, "", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}, nil},
+
+		// Plain OPTIONS requests are no preflight requests.
+		{http.MethodOptions, "https://www.example.com", "", "", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin": "https://www.example.com",
+		}, []string{"options-handler"}},
+	}
+
+	for index, test := range tests {
+		h.Clear()
+
+		r := httptest.NewRequest(test.method, "/", nil)
+		r.Header.Set("Origin", test.origin)
+
+		if len(test.reqMethod) > 0 {
+			r.Header.Set("Access-Control-Request-Method", test.reqMethod)
+		}
+
+		if len(test.reqHdrs) > 0 {
+			r.Header.Set("Access-Control-Request-Headers", test.reqHdrs)
+		}
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+
+		if w.Code != test.code {
+			t.Errorf("Wrong status code: %d != %d (no. %d)", w.Code, test.code, index)
+		}
+
+		for name, value := range test.headers {
+			if actual := w.Header().Get(name); actual != value {
+				t.Errorf("Wrong %s header: %q != %q (no. %d)", name, actual, value, index)
+			}
+		}
+
+		if len(test.writes) != h.Len() {
+			t.Errorf("Wrong write count %d != %d, %v (no. %d)", h.Len(), len(test.writes), h.writes, index)
+		}
+	}
+}
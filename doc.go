@@ -5,7 +5,7 @@
 // Package goserv provides a fast, easy and minimalistic framework for
 // web applications in Go.
 //
-//      goserv requires at least Go v1.6
+//      goserv requires at least Go v1.21
 //
 // Getting Started
 //
@@ -93,6 +93,37 @@
 //
 // Remember to escape the backslash when using custom patterns.
 //
+// A wildcard spanning a complete segment can be named as well. Its value, which may contain
+// slashes, is available like a parameter value. Parameter and wildcard names must be unique
+// within a path:
+//
+//      server.Get("/files/*filepath", handler)
+//
+// Wildcard names consist of ASCII letters, digits and "_". Note that an asterisk starting a
+// segment is always named if a name spans the rest of the segment, so "/x/*html" matches all
+// paths below "/x/" instead of only those ending in "html". Use a custom pattern for the
+// latter, e.g. "/x/:rest(.*html)".
+//
+// The values of unnamed wildcards are available by their position using the RequestContext's
+// Capture method.
+//
+// Instead of a custom pattern a named constraint can be used, e.g. "int", "uint", "alpha", "alnum",
+// "slug", "uuid" or "date". Custom constraints can be added with RegisterConstraint:
+//
+//      server.Get("/users/:user_id<int>", handler)
+//
+// The RequestContext provides typed accessors for parameter values, like ParamInt, ParamUUID
+// or ParamValue, which converts the value using the parameter's constraint.
+//
+// Named Routes
+//
+// A Route can be given a name, which allows building its URL with the Router's URL method
+// instead of hard-coding it. Parameter values are validated against the parameter's pattern:
+//
+//      server.Route("/users/:user_id(\\d+)").Name("user").Get(handler)
+//
+//      url, err := server.URL("user", "user_id", "123") // "/users/123"
+//
 // Strict vs non-strict Slash
 //
 // A Route can have either strict slash or non-strict slash behavior. In non-strict mode paths with or
@@ -102,6 +133,49 @@
 // The behavior can be modified by changing a Router's .StrictSlash property. Sub routers automatically
 // inherit the strict slash behavior from their parent.
 //
+// Groups
+//
+// Middleware registered on a Router applies to all of its Routes. To use different middleware
+// for Routes sharing a path prefix, e.g. public and authenticated API endpoints, a group can be
+// created, which is a sub router sharing the path of its parent:
+//
+//      api.Group(func(private *goserv.Router) {
+//              private.Use(authenticate)
+//              private.Get("/users", listUsers)
+//      })
+//
+// Handlers not built with goserv, e.g. the handlers of net/http/pprof, can be mounted on a
+// prefix with Mount. The prefix is stripped from the request path before the handler is invoked:
+//
+//      server.Mount("/debug", debugMux)
+//
+// HEAD and OPTIONS
+//
+// By default a Router answers HEAD requests using the GET handlers of a Route, in which case the
+// response body is discarded. OPTIONS requests are answered with the "Allow" header listing
+// all methods registered on the matching Routes. Requests with a method for which none of the
+// matching Routes has handlers produce a "method not allowed" error instead of a "not found" error.
+// Both features can be disabled using a Router's .AutoHead and .AutoOptions properties.
+//
+// Host and Scheme
+//
+// Requests can be routed by their Host header using a sub router returned by Host. Labels
+// starting with a ":" capture parameters, which are available like path parameters:
+//
+//      tenants := server.Host(":tenant.example.com")
+//      tenants.Get("/dashboard", handler) // Context(r).Param("tenant")
+//
+// Similarly Scheme and Header return sub routers processing only requests using one of the
+// given schemes or containing a certain header value. All these sub routers share the path of
+// their parent Router.
+//
+// Single Routes can be restricted using matchers like Header, Query, ContentType, Accept or a
+// custom Match function. Routes not matching a request are skipped, which allows dispatching
+// the same path to different handlers:
+//
+//      server.Route("/users").Accept("application/vnd.example.v2+json").Get(usersV2)
+//      server.Route("/users").Get(users)
+//
 // Order matters
 //
 // The order in which handlers are registered does matter, since incoming requests go through the
@@ -111,4 +185,11 @@
 // All sub Routers have no ErrorHandler by default, so all errors are handled by the top level Server. It
 // is possible though to handle errors in a sub Router by setting a custom ErrorHandler.
 //
+// Multiple error handlers can be registered with UseError, optionally restricted to errors
+// matching certain status codes or error types. An error handler can decline an error by
+// calling PassError on the RequestContext, which passes the error to the next error handler
+// and finally up to the parent Router:
+//
+//      api.UseError(jsonErrorHandler, goserv.MatchStatus(400, 499))
+//
 package goserv
@@ -7,6 +7,7 @@ package goserv
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -16,6 +17,12 @@ var (
 	// a response.
 	ErrNotFound = errors.New(http.StatusText(http.StatusNotFound))
 
+	// ErrMethodNotAllowed is passed to the error handler if at least one route
+	// matched the request path, but none of them has handlers for the request
+	// method. The "Allow" response header is set to the methods registered on
+	// the matching routes before the error handler is invoked.
+	ErrMethodNotAllowed = errors.New(http.StatusText(http.StatusMethodNotAllowed))
+
 	// ErrDisallowedHost is passed to the error handler if a handler
 	// created with .AllowedHosts() found a disallowed host.
 	ErrDisallowedHost = errors.New("disallowed host")
@@ -24,15 +31,133 @@ var (
 // StdErrorHandler is the default ErrorHandler added to all Server instances
 // created with NewServer().
 var StdErrorHandler = func(w http.ResponseWriter, r *http.Request, err *ContextError) {
+	err.ApplyHeaders(w.Header())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(err.Code)
-	fmt.Fprintf(w, err.Error())
+	io.WriteString(w, err.PublicMessage())
+}
+
+// An ErrorMatcher decides whether an error handler registered with
+// Router.UseError is responsible for a ContextError.
+type ErrorMatcher func(*ContextError) bool
+
+// MatchStatus returns an ErrorMatcher matching all errors with a
+// code in the range [from, to], e.g. MatchStatus(500, 599).
+func MatchStatus(from, to int) ErrorMatcher {
+	return func(err *ContextError) bool {
+		return err.Code >= from && err.Code <= to
+	}
+}
+
+// MatchError returns an ErrorMatcher matching all errors for which
+// errors.Is reports that they match target.
+func MatchError(target error) ErrorMatcher {
+	return func(err *ContextError) bool {
+		return errors.Is(err.Err, target)
+	}
+}
+
+// MatchErrorType returns an ErrorMatcher matching all errors for which
+// errors.As finds an error of type T.
+func MatchErrorType[T error]() ErrorMatcher {
+	return func(err *ContextError) bool {
+		var target T
+		return errors.As(err.Err, &target)
+	}
 }
 
 // A ContextError stores an error along with a response code usually in the range
 // 4xx or 5xx. The ContextError is passed to the ErrorHandler.
+//
+// The stored error is considered internal, e.g. it may contain details which should
+// not be revealed to clients. The Message is shown to clients instead, if set.
 type ContextError struct {
 	Err  error
 	Code int
+
+	// Public message shown to clients by the error handlers instead of
+	// the stored error's message.
+	Message string
+
+	// Headers added to the error response by the error handlers,
+	// e.g. "Retry-After" or "WWW-Authenticate".
+	Header http.Header
+}
+
+// NewContextError returns a new ContextError with the specified code. If err is nil
+// the status text of the code is used as error.
+func NewContextError(code int, err error) *ContextError {
+	if err == nil {
+		err = errors.New(http.StatusText(code))
+	}
+
+	return &ContextError{Err: err, Code: code}
+}
+
+// BadRequest is a shortcut for NewContextError(http.StatusBadRequest, err).
+func BadRequest(err error) *ContextError {
+	return NewContextError(http.StatusBadRequest, err)
+}
+
+// Unauthorized is a shortcut for NewContextError(http.StatusUnauthorized, err).
+func Unauthorized(err error) *ContextError {
+	return NewContextError(http.StatusUnauthorized, err)
+}
+
+// Forbidden is a shortcut for NewContextError(http.StatusForbidden, err).
+func Forbidden(err error) *ContextError {
+	return NewContextError(http.StatusForbidden, err)
+}
+
+// NotFound is a shortcut for NewContextError(http.StatusNotFound, err).
+func NotFound(err error) *ContextError {
+	return NewContextError(http.StatusNotFound, err)
+}
+
+// Conflict is a shortcut for NewContextError(http.StatusConflict, err).
+func Conflict(err error) *ContextError {
+	return NewContextError(http.StatusConflict, err)
+}
+
+// UnsupportedMediaType is a shortcut for NewContextError(http.StatusUnsupportedMediaType, err).
+func UnsupportedMediaType(err error) *ContextError {
+	return NewContextError(http.StatusUnsupportedMediaType, err)
+}
+
+// UnprocessableEntity is a shortcut for NewContextError(http.StatusUnprocessableEntity, err).
+func UnprocessableEntity(err error) *ContextError {
+	return NewContextError(http.StatusUnprocessableEntity, err)
+}
+
+// TooManyRequests is a shortcut for NewContextError(http.StatusTooManyRequests, err).
+func TooManyRequests(err error) *ContextError {
+	return NewContextError(http.StatusTooManyRequests, err)
+}
+
+// InternalServerError is a shortcut for NewContextError(http.StatusInternalServerError, err).
+func InternalServerError(err error) *ContextError {
+	return NewContextError(http.StatusInternalServerError, err)
+}
+
+// ServiceUnavailable is a shortcut for NewContextError(http.StatusServiceUnavailable, err).
+func ServiceUnavailable(err error) *ContextError {
+	return NewContextError(http.StatusServiceUnavailable, err)
+}
+
+// WithMessage sets the public message and returns the ContextError itself.
+func (c *ContextError) WithMessage(msg string) *ContextError {
+	c.Message = msg
+	return c
+}
+
+// WithHeader adds a response header and returns the ContextError itself.
+func (c *ContextError) WithHeader(name, value string) *ContextError {
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+
+	c.Header.Add(name, value)
+	return c
 }
 
 // Error returns the result of calling .Error() on the stored error.
@@ -40,7 +165,46 @@ func (c *ContextError) Error() string {
 	return c.Err.Error()
 }
 
+// Unwrap returns the stored error, which allows inspecting
+// it with errors.Is and errors.As.
+func (c *ContextError) Unwrap() error {
+	return c.Err
+}
+
+// PublicMessage returns the Message, if set, otherwise the stored error's message.
+func (c *ContextError) PublicMessage() string {
+	if len(c.Message) > 0 {
+		return c.Message
+	}
+
+	return c.Error()
+}
+
+// ApplyHeaders adds the ContextError's headers to h.
+func (c *ContextError) ApplyHeaders(h http.Header) {
+	for name, values := range c.Header {
+		for _, value := range values {
+			h.Add(name, value)
+		}
+	}
+}
+
 // String returns a formatted string with this format: (<code>) <error>.
 func (c *ContextError) String() string {
 	return fmt.Sprintf("(%d) %s", c.Code, c.Err)
 }
+
+// A PanicError is passed to the error handler, wrapped in a ContextError, if
+// a Router with enabled panic recovery recovered from a panic.
+type PanicError struct {
+	// The value passed to panic.
+	Value interface{}
+
+	// Stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error returns a formatted string with this format: Panic: <value>.
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("Panic: %v", p.Value)
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextError(t *testing.T) {
+	cause := errors.New("database connection lost")
+
+	err := ServiceUnavailable(cause).WithMessage("try again later").WithHeader("Retry-After", "120")
+
+	if err.Code != http.StatusServiceUnavailable {
+		t.Errorf("Wrong code: %d != %d", err.Code, http.StatusServiceUnavailable)
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("Expected errors.Is to find the cause")
+	}
+
+	var ctxErr *ContextError
+	if !errors.As(error(err), &ctxErr) || ctxErr != err {
+		t.Error("Expected errors.As to find the ContextError")
+	}
+
+	if msg := err.PublicMessage(); msg != "try again later" {
+		t.Errorf("Wrong public message: %q", msg)
+	}
+
+	if msg := NotFound(nil).PublicMessage(); msg != "Not Found" {
+		t.Errorf("Wrong default message: %q", msg)
+	}
+
+	w := httptest.NewRecorder()
+	StdErrorHandler(w, httptest.NewRequest(http.MethodGet, "/", nil), err)
+
+	if w.Body.String() != "try again later" {
+		t.Errorf("Wrong body: %q", w.Body.String())
+	}
+
+	if v := w.Header().Get("Retry-After"); v != "120" {
+		t.Errorf("Wrong Retry-After header: %q", v)
+	}
+}
+
+func TestRequestContextSetAppendError(t *testing.T) {
+	ctx := newRequestContext()
+
+	first, second := errors.New("first"), errors.New("second")
+
+	ctx.AppendError(BadRequest(first))
+	ctx.AppendError(Unauthorized(second).WithHeader("WWW-Authenticate", "Basic"))
+
+	err := ctx.Err()
+	if err.Code != http.StatusBadRequest {
+		t.Errorf("Wrong code: %d != %d", err.Code, http.StatusBadRequest)
+	}
+
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("Expected both errors to be appended: %v", err)
+	}
+
+	if v := err.Header.Get("WWW-Authenticate"); v != "Basic" {
+		t.Errorf("Wrong WWW-Authenticate header: %q", v)
+	}
+
+	replacement := InternalServerError(nil)
+	ctx.SetError(replacement)
+
+	if ctx.Err() != replacement {
+		t.Error("Expected the error to be replaced")
+	}
+}
@@ -13,7 +13,8 @@ import (
 // is responsible for handling errors that occur during the
 // request processing.
 //
-// A ErrorHandlerFunc should always write a response!
+// A ErrorHandlerFunc should always write a response, unless it declines
+// the error using RequestContext.PassError!
 type ErrorHandlerFunc func(http.ResponseWriter, *http.Request, *ContextError)
 
 // A ParamHandlerFunc can be registered to a Router using a parameter's name.
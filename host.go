@@ -0,0 +1,190 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Host returns a new sub router processing only requests whose Host header matches
+// the specified pattern. The sub router shares the path of its parent.
+//
+// The pattern consists of labels separated by periods. Labels starting with a ":" are
+// parameters capturing a single label, e.g. ":tenant.example.com" matches
+// "acme.example.com". Parameter values are available using RequestContext.Param.
+// Labels consisting of a single "*" match any label without capturing it.
+// Hosts are compared case-insensitively and the port is ignored, unless the
+// pattern contains one.
+//
+// Requests not handled by the sub router are passed on to the Routes registered
+// after it. Like SubRouter this function returns the new sub router instead of the
+// parent router. Invalid patterns cause a panic.
+func (r *Router) Host(pattern string) *Router {
+	matcher, err := hostMatcher(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return r.scope(matcher)
+}
+
+// Scheme returns a new sub router processing only requests using one of the
+// specified schemes, e.g. "https". The scheme is taken from the request URL, if
+// present, otherwise it is "https" for TLS connections and "http" for all others.
+//
+// The sub router shares the path of its parent. Like SubRouter this function
+// returns the new sub router instead of the parent router.
+func (r *Router) Scheme(schemes ...string) *Router {
+	return r.scope(func(req *http.Request, _ params) bool {
+		scheme := requestScheme(req)
+
+		for _, s := range schemes {
+			if strings.EqualFold(s, scheme) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Header returns a new sub router processing only requests with the specified
+// header value. If value is "" the header must only be present.
+//
+// The sub router shares the path of its parent. Like SubRouter this function
+// returns the new sub router instead of the parent router.
+func (r *Router) Header(key, value string) *Router {
+	return r.scope(headerMatcher(key, value))
+}
+
+// Returns a new scoped sub router mounted on the Router's own path, which
+// processes only requests accepted by all matchers. The scope is only entered
+// if one of its Routes matches the request path, so its middleware isn't
+// invoked for requests handled by the Routes registered after the scope.
+func (r *Router) scope(matchers ...requestMatcher) *Router {
+	router := r.newSubRouter(r.path)
+	router.scoped = true
+
+	route := newScopeRoute(r.StrictSlash).All(router.serveHTTP)
+	route.router = router
+	route.matchers = append(matchers, func(req *http.Request, _ params) bool {
+		return router.matchesRoute(req)
+	})
+	r.addRoute(route)
+
+	return router
+}
+
+// Returns the scheme of the request, see Router.Scheme.
+func requestScheme(req *http.Request) string {
+	if len(req.URL.Scheme) > 0 {
+		return strings.ToLower(req.URL.Scheme)
+	}
+
+	if req.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// Returns a requestMatcher matching the Host header against the pattern
+// and capturing the values of all parameters.
+func hostMatcher(pattern string) (requestMatcher, error) {
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("Hosts must not be empty")
+	}
+
+	var rx strings.Builder
+	var names []string
+
+	rx.WriteString("(?i)^")
+
+	// Only a numeric suffix after the last label is a port, a ":"
+	// at the start of a label starts a parameter.
+	host, port := pattern, ""
+	if i := strings.LastIndex(pattern, ":"); i > 0 && i > strings.LastIndex(pattern, ".") {
+		if _, err := strconv.Atoi(pattern[i+1:]); err == nil {
+			host, port = pattern[:i], pattern[i+1:]
+		}
+	}
+
+	withPort := len(port) > 0
+
+	for index, label := range strings.Split(host, ".") {
+		if index > 0 {
+			rx.WriteString(`\.`)
+		}
+
+		switch {
+		case label == "*":
+			rx.WriteString(`[^.]+`)
+		case strings.HasPrefix(label, ":"):
+			name := label[1:]
+
+			if len(name) == 0 {
+				return nil, fmt.Errorf("Invalid host %q, missing parameter name", pattern)
+			}
+
+			for _, r := range name {
+				if !isAlphaNumDash(r) {
+					return nil, fmt.Errorf("Invalid host %q, invalid rune '%c' in parameter name", pattern, r)
+				}
+			}
+
+			for _, n := range names {
+				if n == name {
+					return nil, fmt.Errorf("Invalid host %q, duplicate parameter name '%s'", pattern, name)
+				}
+			}
+
+			// Values are assigned by position, since not all valid
+			// parameter names are valid group names.
+			names = append(names, name)
+			rx.WriteString("([^.]+)")
+		case len(label) == 0:
+			return nil, fmt.Errorf("Invalid host %q, empty label", pattern)
+		default:
+			rx.WriteString(regexp.QuoteMeta(label))
+		}
+	}
+
+	if withPort {
+		rx.WriteString(":" + regexp.QuoteMeta(port))
+	}
+
+	rx.WriteString("$")
+
+	hostRx, err := regexp.Compile(rx.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return func(req *http.Request, params params) bool {
+		host := req.Host
+
+		if !withPort {
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+		}
+
+		matches := hostRx.FindStringSubmatch(host)
+		if matches == nil {
+			return false
+		}
+
+		for index, name := range names {
+			params[name] = matches[index+1]
+		}
+
+		return true
+	}, nil
+}
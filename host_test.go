@@ -0,0 +1,125 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostMatcher(t *testing.T) {
+	tests := []struct {
+		Pattern, Host string
+		Match         bool
+		Params        params
+		Err           error
+	}{
+		{Pattern: "example.com", Host: "example.com", Match: true},
+		{Pattern: "example.com", Host: "EXAMPLE.com:8080", Match: true},
+		{Pattern: "example.com", Host: "www.example.com", Match: false},
+		{Pattern: ":tenant.example.com", Host: "acme.example.com", Match: true, Params: params{"tenant": "acme"}},
+		{Pattern: ":tenant.example.com", Host: "a.b.example.com", Match: false},
+		{Pattern: ":sub.:tenant.example.com", Host: "api.acme.example.com", Match: true, Params: params{"sub": "api", "tenant": "acme"}},
+		{Pattern: "*.example.com", Host: "www.example.com", Match: true},
+		{Pattern: "localhost:8080", Host: "localhost:8080", Match: true},
+		{Pattern: "localhost:8080", Host: "localhost:9090", Match: false},
+		{Pattern: ":tenant:8080", Host: "acme:8080", Match: true, Params: params{"tenant": "acme"}},
+		{Pattern: ":ten-ant.example.com", Host: "acme.example.com", Match: true, Params: params{"ten-ant": "acme"}},
+
+		// NEGATIVE TESTS //
+		{Pattern: "", Err: fmt.Errorf("Hosts must not be empty")},
+		{Pattern: "example..com", Err: fmt.Errorf("Invalid host \"example..com\", empty label")},
+		{Pattern: ":.example.com", Err: fmt.Errorf("Invalid host \":.example.com\", missing parameter name")},
+		{Pattern: ":a$.example.com", Err: fmt.Errorf("Invalid host \":a$.example.com\", invalid rune '$' in parameter name")},
+		{Pattern: ":a.:a.com", Err: fmt.Errorf("Invalid host \":a.:a.com\", duplicate parameter name 'a'")},
+	}
+
+	for index, test := range tests {
+		matcher, err := hostMatcher(test.Pattern)
+
+		if test.Err != nil {
+			if err == nil || err.Error() != test.Err.Error() {
+				t.Errorf("Wrong error: %v != %v (no. %d)", err, test.Err, index)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v (no. %d)", err, index)
+			continue
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = test.Host
+
+		values := make(params)
+		if match := matcher(req, values); match != test.Match {
+			t.Errorf("Wrong match result: %v != %v (no. %d)", match, test.Match, index)
+		}
+
+		for name, value := range test.Params {
+			if values[name] != value {
+				t.Errorf("Wrong value for parameter '%s': %s != %s (no. %d)", name, values[name], value, index)
+			}
+		}
+	}
+}
+
+func TestRouterHost(t *testing.T) {
+	server := NewServer()
+	history := &historyWriter{}
+	h := historyHandler{history}
+
+	server.Host("api.example.com").Get("/users", h.WriteHandler("api"))
+	server.Host(":tenant.example.com").Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		WriteString(w, "tenant "+Context(r).Param("tenant"))
+	})
+	server.Scheme("https").Get("/secure", h.WriteHandler("https"))
+	server.Header("X-Version", "2").Get("/secure", h.WriteHandler("v2"))
+	server.Get("/users", h.WriteHandler("default"))
+
+	tests := []struct {
+		Host, Path string
+		TLS        bool
+		Header     http.Header
+		Body       string
+		Status     int
+	}{
+		{Host: "api.example.com", Path: "/users", Body: "api"},
+		{Host: "acme.example.com", Path: "/users", Body: "tenant acme"},
+		{Host: "localhost", Path: "/users", Body: "default"},
+		{Host: "acme.example.com", Path: "/secure", TLS: true, Body: "https"},
+		{Host: "acme.example.com", Path: "/secure", Header: http.Header{"X-Version": {"2"}}, Body: "v2"},
+		{Host: "acme.example.com", Path: "/secure", Status: http.StatusNotFound},
+	}
+
+	for index, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.Path, nil)
+		req.Host = test.Host
+
+		if test.TLS {
+			req.TLS = &tls.ConnectionState{}
+		}
+
+		for key, values := range test.Header {
+			req.Header[key] = values
+		}
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		if test.Status != 0 && w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+
+		if len(test.Body) > 0 && w.Body.String() != test.Body {
+			t.Errorf("Wrong body: %q != %q (no. %d)", w.Body.String(), test.Body, index)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// A requestMatcher reports whether a request matches and stores all
+// captured parameter values in params.
+type requestMatcher func(req *http.Request, params params) bool
+
+// Match adds a custom matcher to the Route. Requests for which fn returns
+// false are not processed by the Route.
+//
+// A Route can have any number of matchers, which must all match the request in
+// addition to the path. Routes not matching a request are skipped, as if their path
+// didn't match, so multiple Routes with the same path can dispatch requests
+// to different handlers, e.g. depending on a header:
+//
+//	server.Route("/users").Accept("application/vnd.example.v2+json").Get(usersV2)
+//	server.Route("/users").Get(users)
+func (r *Route) Match(fn func(*http.Request) bool) *Route {
+	return r.addMatcher(func(req *http.Request, _ params) bool {
+		return fn(req)
+	})
+}
+
+// Header adds a matcher to the Route, which requires the request to have the
+// specified header value. If value is "" the header must only be present.
+func (r *Route) Header(key, value string) *Route {
+	return r.addMatcher(headerMatcher(key, value))
+}
+
+// HeaderRegexp adds a matcher to the Route, which requires a value of the specified
+// header to match the regular expression. Invalid expressions cause a panic.
+func (r *Route) HeaderRegexp(key, pattern string) *Route {
+	key = http.CanonicalHeaderKey(key)
+	rx := regexp.MustCompile(pattern)
+
+	return r.addMatcher(func(req *http.Request, _ params) bool {
+		for _, v := range req.Header[key] {
+			if rx.MatchString(v) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Query adds a matcher to the Route, which requires all specified query
+// parameters to be present in the request URL.
+func (r *Route) Query(keys ...string) *Route {
+	return r.addMatcher(func(req *http.Request, _ params) bool {
+		query := req.URL.Query()
+
+		for _, key := range keys {
+			if _, ok := query[key]; !ok {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// ContentType adds a matcher to the Route, which requires the request's Content-Type
+// header to be one of the specified media types. Parameters like the charset are
+// ignored and a subtype "*" matches all subtypes, e.g. "text/*".
+func (r *Route) ContentType(mediaTypes ...string) *Route {
+	return r.addMatcher(func(req *http.Request, _ params) bool {
+		typ, subtype := splitMediaType(req.Header.Get("Content-Type"))
+		if len(typ) == 0 {
+			return false
+		}
+
+		for _, mediaType := range mediaTypes {
+			t, s := splitMediaType(mediaType)
+
+			if t == typ && (s == subtype || s == "*") {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Accept adds a matcher to the Route, which requires the request's Accept header
+// to accept one of the specified media types. Media ranges are considered as
+// well, e.g. "application/*" accepts "application/json".
+//
+// Requests without an Accept header accept all media types. Therefore Routes using
+// Accept for versioning should be registered before the Route handling the
+// default version.
+func (r *Route) Accept(mediaTypes ...string) *Route {
+	return r.addMatcher(func(req *http.Request, _ params) bool {
+		return len(negotiateContentType(req.Header.Get("Accept"), mediaTypes)) > 0
+	})
+}
+
+func (r *Route) addMatcher(matcher requestMatcher) *Route {
+	r.matchers = append(r.matchers, matcher)
+	return r
+}
+
+// Returns true if the request is accepted by all matchers. Parameter values
+// are only stored in params if all matchers match.
+func (r *Route) matchRequest(req *http.Request, params params) bool {
+	if len(r.matchers) == 0 {
+		return true
+	}
+
+	captured := make(map[string]string)
+	for _, matcher := range r.matchers {
+		if !matcher(req, captured) {
+			return false
+		}
+	}
+
+	for name, value := range captured {
+		params[name] = value
+	}
+
+	return true
+}
+
+// Returns a requestMatcher requiring the header value, see Route.Header.
+func headerMatcher(key, value string) requestMatcher {
+	key = http.CanonicalHeaderKey(key)
+
+	return func(req *http.Request, _ params) bool {
+		values, ok := req.Header[key]
+		if !ok {
+			return false
+		}
+
+		if len(value) == 0 {
+			return true
+		}
+
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	}
+}
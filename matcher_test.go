@@ -0,0 +1,69 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteMatchers(t *testing.T) {
+	server := NewServer()
+	history := &historyWriter{}
+	h := historyHandler{history}
+
+	server.Route("/users").Accept("application/vnd.x.v2+json").Get(h.WriteHandler("v2"))
+	server.Route("/users").Header("X-Debug", "").Get(h.WriteHandler("debug"))
+	server.Route("/users").HeaderRegexp("User-Agent", "^curl/").Get(h.WriteHandler("curl"))
+	server.Route("/users").Query("page", "size").Get(h.WriteHandler("paged"))
+	server.Route("/users").Match(func(r *http.Request) bool {
+		return strings.HasPrefix(r.RemoteAddr, "10.")
+	}).Get(h.WriteHandler("internal"))
+	server.Route("/users").Get(h.WriteHandler("v1"))
+
+	server.Route("/upload").ContentType("application/json").Post(h.WriteHandler("json"))
+	server.Route("/upload").ContentType("text/*").Post(h.WriteHandler("text"))
+
+	tests := []struct {
+		Method, Path string
+		Header       http.Header
+		RemoteAddr   string
+		Body         string
+		Status       int
+	}{
+		{Method: http.MethodGet, Path: "/users", Header: http.Header{"Accept": {"application/vnd.x.v2+json"}}, Body: "v2"},
+		{Method: http.MethodGet, Path: "/users", Header: http.Header{"Accept": {"application/json"}}, Body: "v1"},
+		{Method: http.MethodGet, Path: "/users", Header: http.Header{"Accept": {"application/json"}, "X-Debug": {"1"}}, Body: "debug"},
+		{Method: http.MethodGet, Path: "/users", Header: http.Header{"Accept": {"text/html"}, "User-Agent": {"curl/8.0"}}, Body: "curl"},
+		{Method: http.MethodGet, Path: "/users?page=1&size=10", Header: http.Header{"Accept": {"text/html"}}, Body: "paged"},
+		{Method: http.MethodGet, Path: "/users?page=1", Header: http.Header{"Accept": {"text/html"}}, Body: "v1"},
+		{Method: http.MethodGet, Path: "/users", Header: http.Header{"Accept": {"text/html"}}, RemoteAddr: "10.0.0.1:1234", Body: "internal"},
+		{Method: http.MethodPost, Path: "/upload", Header: http.Header{"Content-Type": {"application/json; charset=utf-8"}}, Body: "json"},
+		{Method: http.MethodPost, Path: "/upload", Header: http.Header{"Content-Type": {"text/plain"}}, Body: "text"},
+		{Method: http.MethodPost, Path: "/upload", Header: http.Header{"Content-Type": {"image/png"}}, Status: http.StatusNotFound},
+	}
+
+	for index, test := range tests {
+		req := httptest.NewRequest(test.Method, test.Path, nil)
+		req.Header = test.Header
+
+		if len(test.RemoteAddr) > 0 {
+			req.RemoteAddr = test.RemoteAddr
+		}
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		if test.Status != 0 && w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+
+		if len(test.Body) > 0 && w.Body.String() != test.Body {
+			t.Errorf("Wrong body: %q != %q (no. %d)", w.Body.String(), test.Body, index)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount registers the handler for all requests whose path starts with prefix,
+// e.g. to embed third-party handlers. The prefix only matches complete segments,
+// i.e. "/debug" matches "/debug" and "/debug/pprof", but not "/debugger".
+//
+// The request is handled once the handler returns. If the handler didn't write a
+// response, "200 OK" is sent like by the http package.
+//
+// The prefix, including the path of the Router, is stripped from the request's URL.Path
+// and URL.RawPath before the handler is invoked and is available using
+// RequestContext.ForwardedPrefix. The original path is restored after the handler
+// returned. The prefix can contain parameters.
+//
+//	server.Mount("/debug/pprof", http.HandlerFunc(pprof.Index))
+func (r *Router) Mount(prefix string, handler http.Handler) *Router {
+	route := newRoute(prefix, r.StrictSlash, true)
+
+	route.addMatcher(func(req *http.Request, _ params) bool {
+		_, _, ok := r.splitMountPath(route, req)
+		return ok
+	})
+
+	route.All(func(w http.ResponseWriter, req *http.Request) {
+		forwarded, rest, ok := r.splitMountPath(route, req)
+		if !ok {
+			return
+		}
+
+		ctx := Context(req)
+		prevPrefix, prevPath, prevRawPath := ctx.forwardedPrefix, req.URL.Path, req.URL.RawPath
+
+		defer func() {
+			ctx.forwardedPrefix, req.URL.Path, req.URL.RawPath = prevPrefix, prevPath, prevRawPath
+		}()
+
+		ctx.forwardedPrefix = prevPrefix + forwarded
+		req.URL.Path = rest
+		req.URL.RawPath = stripRawPath(req.URL.RawPath, forwarded)
+
+		if p, ok := handler.(passHandler); ok {
+			if !p.serveOrPass(w, req) {
+				return
+			}
+		} else {
+			handler.ServeHTTP(w, req)
+		}
+
+		// Like the http package respond with "200 OK" if the
+		// handler returned without writing a response.
+		if !internalWriter(w).Written() && ctx.err == nil {
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	r.addRoute(route)
+
+	return r
+}
+
+// A passHandler is a mounted handler which can pass requests on to the Routes
+// registered after it, e.g. the file server passes on requests to missing files.
+type passHandler interface {
+	// serveOrPass handles the request like ServeHTTP and returns true, or
+	// returns false without writing anything to pass the request on.
+	serveOrPass(w http.ResponseWriter, r *http.Request) bool
+}
+
+// Splits the request path into the prefix matched by the mount Route, including
+// the path of the Router, and the remaining path. Returns false if the prefix
+// doesn't end at a segment boundary.
+func (r *Router) splitMountPath(route *Route, req *http.Request) (string, string, bool) {
+	fullPath := SanitizePath(req.URL.Path)
+	path := strings.TrimPrefix(fullPath, r.path)
+
+	n := route.path.PrefixLength(path)
+	if n < 0 {
+		return "", "", false
+	}
+
+	// Keep a trailing slash of the prefix in the remaining path.
+	if n > 0 && path[n-1] == '/' {
+		n--
+	}
+
+	rest := path[n:]
+	if len(rest) > 0 && rest[0] != '/' {
+		return "", "", false
+	}
+
+	if len(rest) == 0 {
+		rest = "/"
+	}
+
+	return fullPath[:len(fullPath)-len(path)+n], rest, true
+}
+
+// Strips the escaped prefix from rawPath. If rawPath doesn't start with the
+// escaped prefix "" is returned, which makes the url package use the path.
+func stripRawPath(rawPath, prefix string) string {
+	if len(rawPath) == 0 {
+		return ""
+	}
+
+	rest, ok := strings.CutPrefix(rawPath, (&url.URL{Path: prefix}).EscapedPath())
+	if !ok {
+		return ""
+	}
+
+	if len(rest) == 0 {
+		return "/"
+	}
+
+	return rest
+}
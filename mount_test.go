@@ -0,0 +1,81 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterMount(t *testing.T) {
+	server := NewServer()
+
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s", r.URL.Path, r.URL.EscapedPath(), Context(r).ForwardedPrefix())
+	})
+
+	var after string
+	server.Use(func(w http.ResponseWriter, r *http.Request) {
+		Context(r).Defer(func() { after = r.URL.Path })
+	})
+
+	server.Mount("/debug", echo)
+	server.SubRouter("/api").Mount("/v1/", echo).Mount("/tenants/:tenant", echo)
+	server.Get("/debugger", func(w http.ResponseWriter, r *http.Request) {
+		WriteString(w, "debugger")
+	})
+
+	tests := []struct {
+		Path, Body string
+	}{
+		{"/debug", "/|/|/debug"},
+		{"/debug/pprof/heap", "/pprof/heap|/pprof/heap|/debug"},
+		{"/debug/a%2Fb", "/a/b|/a%2Fb|/debug"},
+		{"/debugger", "debugger"},
+		{"/api/v1/users", "/users|/users|/api/v1"},
+		{"/api/tenants/acme/users", "/users|/users|/api/tenants/acme"},
+	}
+
+	for index, test := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.Path, nil))
+
+		if body := w.Body.String(); body != test.Body {
+			t.Errorf("Wrong body: %s != %s (no. %d)", body, test.Body, index)
+		}
+
+		if r, _ := http.NewRequest(http.MethodGet, test.Path, nil); after != r.URL.Path {
+			t.Errorf("Path not restored: %s != %s (no. %d)", after, r.URL.Path, index)
+		}
+	}
+}
+
+func TestRouterMountImplicitStatus(t *testing.T) {
+	server := NewServer()
+	server.Mount("/noop", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Mount("/error", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Context(r).Error(fmt.Errorf("failed"), http.StatusBadGateway)
+	}))
+
+	tests := []struct {
+		Path   string
+		Status int
+	}{
+		{"/noop", http.StatusOK},
+		{"/noop/x", http.StatusOK},
+		{"/error", http.StatusBadGateway},
+	}
+
+	for index, test := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.Path, nil))
+
+		if w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+	}
+}
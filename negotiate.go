@@ -0,0 +1,116 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// Returns the specificity of the mediaRange if it matches the media type
+// or -1 if it doesn't match.
+func (m mediaRange) match(typ, subtype string) int {
+	switch {
+	case m.typ == typ && m.subtype == subtype:
+		return 2
+	case m.typ == typ && m.subtype == "*":
+		return 1
+	case m.typ == "*" && m.subtype == "*":
+		return 0
+	}
+
+	return -1
+}
+
+// Parses the media ranges of an Accept header. Parameters other than
+// the quality value are ignored.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, entry := range strings.Split(accept, ",") {
+		params := strings.Split(entry, ";")
+		typ, subtype := splitMediaType(params[0])
+
+		if len(typ) == 0 {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+
+			if strings.TrimSpace(name) != "q" {
+				continue
+			}
+
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = v
+			}
+		}
+
+		ranges = append(ranges, mediaRange{typ, subtype, q})
+	}
+
+	return ranges
+}
+
+// Splits a media type into lower case type and subtype and strips all
+// parameters. Returns empty strings for invalid media types.
+func splitMediaType(mediaType string) (string, string) {
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+
+	typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+	if !ok || len(typ) == 0 || len(subtype) == 0 {
+		return "", ""
+	}
+
+	return typ, subtype
+}
+
+// negotiateContentType returns the offered media type preferred by the
+// Accept header. The quality of each offer is determined by the most specific
+// matching media range. If multiple offers have the same quality, the first
+// one wins.
+//
+// If the Accept header is empty the first offer is returned. If none of the
+// offers is acceptable "" is returned.
+func negotiateContentType(accept string, offers []string) string {
+	if len(strings.TrimSpace(accept)) == 0 {
+		if len(offers) == 0 {
+			return ""
+		}
+
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+
+	for _, offer := range offers {
+		typ, subtype := splitMediaType(offer)
+
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := r.match(typ, subtype); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,136 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A ParamConstraint restricts the values of a parameter using a regular
+// expression and optionally converts matched values.
+//
+// Constraints are referenced by name in a Route's path, e.g. "/users/:id<int>".
+type ParamConstraint struct {
+	// Regular expression matching valid values.
+	Pattern string
+
+	// Converts a matched value, used by RequestContext.ParamValue.
+	// If nil, the value is returned as string.
+	Parse func(string) (interface{}, error)
+}
+
+var constraintMutex sync.RWMutex
+var constraints = map[string]*ParamConstraint{
+	"int": {`-?[0-9]+`, func(v string) (interface{}, error) {
+		return strconv.Atoi(v)
+	}},
+	"uint": {`[0-9]+`, func(v string) (interface{}, error) {
+		return strconv.ParseUint(v, 10, 64)
+	}},
+	"alpha": {`[a-zA-Z]+`, nil},
+	"alnum": {`[a-zA-Z0-9]+`, nil},
+	"slug":  {`[a-z0-9]+(?:-[a-z0-9]+)*`, nil},
+	"uuid": {`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, func(v string) (interface{}, error) {
+		return ParseUUID(v)
+	}},
+	"date": {`[0-9]{4}-[0-9]{2}-[0-9]{2}`, func(v string) (interface{}, error) {
+		return time.Parse("2006-01-02", v)
+	}},
+}
+
+// RegisterConstraint registers a named ParamConstraint, which can then be used
+// in paths registered afterwards. An existing constraint with the same name,
+// including the built-in ones, is replaced.
+//
+// The built-in constraints are "int", "uint", "alpha", "alnum", "slug", "uuid"
+// and "date" (YYYY-MM-DD).
+func RegisterConstraint(name, pattern string, parse func(string) (interface{}, error)) {
+	constraintMutex.Lock()
+	constraints[name] = &ParamConstraint{pattern, parse}
+	constraintMutex.Unlock()
+}
+
+func lookupConstraint(name string) *ParamConstraint {
+	constraintMutex.RLock()
+	defer constraintMutex.RUnlock()
+	return constraints[name]
+}
+
+// A UUID is a 128 bit universally unique identifier as specified by RFC 4122.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in the canonical form
+// "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx".
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	return u, nil
+}
+
+// String returns the UUID in the canonical form.
+func (u UUID) String() string {
+	b := hex.EncodeToString(u[:])
+	return b[0:8] + "-" + b[8:12] + "-" + b[12:16] + "-" + b[16:20] + "-" + b[20:]
+}
+
+// ParamInt returns the parameter value converted to an int.
+func (r *RequestContext) ParamInt(name string) (int, error) {
+	v, err := strconv.Atoi(r.Param(name))
+	return v, paramError(name, err)
+}
+
+// ParamInt64 returns the parameter value converted to an int64.
+func (r *RequestContext) ParamInt64(name string) (int64, error) {
+	v, err := strconv.ParseInt(r.Param(name), 10, 64)
+	return v, paramError(name, err)
+}
+
+// ParamUUID returns the parameter value converted to a UUID.
+func (r *RequestContext) ParamUUID(name string) (UUID, error) {
+	v, err := ParseUUID(r.Param(name))
+	return v, paramError(name, err)
+}
+
+// ParamTime returns the parameter value parsed with time.Parse using the given layout.
+func (r *RequestContext) ParamTime(name, layout string) (time.Time, error) {
+	v, err := time.Parse(layout, r.Param(name))
+	return v, paramError(name, err)
+}
+
+// ParamValue returns the parameter value converted by the Parse function of the
+// parameter's constraint. Without a constraint or Parse function the value is
+// returned as string.
+func (r *RequestContext) ParamValue(name string) (interface{}, error) {
+	value := r.Param(name)
+
+	constraint := r.constraints[name]
+	if constraint == nil || constraint.Parse == nil {
+		return value, nil
+	}
+
+	v, err := constraint.Parse(value)
+	return v, paramError(name, err)
+}
+
+func paramError(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("parameter %q: %w", name, err)
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTypedParams(t *testing.T) {
+	RegisterConstraint("upper", `[A-Z]+`, func(v string) (interface{}, error) {
+		return strings.ToLower(v), nil
+	})
+
+	server := NewServer()
+
+	var ctx *RequestContext
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		ctx = Context(r)
+		WriteString(w, "ok")
+	}
+
+	server.Get("/ints/:id<int>/:big", handler)
+	server.Get("/uuids/:id<uuid>", handler)
+	server.Get("/dates/:day<date>", handler)
+	server.Get("/custom/:name<upper>", handler)
+
+	serve := func(path string) int {
+		ctx = nil
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	if code := serve("/ints/abc/1"); code != http.StatusNotFound {
+		t.Errorf("Expected constraint to reject value, got status %d", code)
+	}
+
+	if serve("/ints/-42/9000000000"); ctx == nil {
+		t.Fatal("Expected handler to be invoked")
+	}
+
+	if v, err := ctx.ParamInt("id"); err != nil || v != -42 {
+		t.Errorf("Wrong ParamInt: %v, %v", v, err)
+	}
+
+	if v, err := ctx.ParamInt64("big"); err != nil || v != 9000000000 {
+		t.Errorf("Wrong ParamInt64: %v, %v", v, err)
+	}
+
+	if v, err := ctx.ParamValue("id"); err != nil || v != -42 {
+		t.Errorf("Wrong ParamValue: %#v, %v", v, err)
+	}
+
+	if v, err := ctx.ParamValue("big"); err != nil || v != "9000000000" {
+		t.Errorf("Wrong ParamValue without constraint: %#v, %v", v, err)
+	}
+
+	if _, err := ctx.ParamInt("missing"); err == nil {
+		t.Error("Expected error for missing parameter")
+	}
+
+	serve("/uuids/123E4567-e89b-12d3-a456-426614174000")
+	if v, err := ctx.ParamUUID("id"); err != nil || v.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("Wrong ParamUUID: %v, %v", v, err)
+	}
+
+	serve("/dates/2016-05-12")
+	if v, err := ctx.ParamTime("day", "2006-01-02"); err != nil || !v.Equal(time.Date(2016, 5, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong ParamTime: %v, %v", v, err)
+	}
+
+	serve("/custom/HELLO")
+	if v, err := ctx.ParamValue("name"); err != nil || v != "hello" {
+		t.Errorf("Wrong ParamValue with custom constraint: %#v, %v", v, err)
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	for _, invalid := range []string{"", "123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g"} {
+		if _, err := ParseUUID(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+}
+
+func TestWildcardParams(t *testing.T) {
+	server := NewServer()
+
+	var ctx *RequestContext
+	server.Get("/files/*filepath", func(w http.ResponseWriter, r *http.Request) {
+		ctx = Context(r)
+	})
+	server.Get("/raw/*/*", func(w http.ResponseWriter, r *http.Request) {
+		ctx = Context(r)
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/files/css/main.css", nil))
+	if v := ctx.Param("filepath"); v != "css/main.css" {
+		t.Errorf("Wrong wildcard value: %s != css/main.css", v)
+	}
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/raw/a/b", nil))
+	if v1, v2, v3 := ctx.Capture(0), ctx.Capture(1), ctx.Capture(2); v1 != "a" || v2 != "b" || v3 != "" {
+		t.Errorf("Wrong captures: %q, %q, %q", v1, v2, v3)
+	}
+}
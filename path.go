@@ -7,6 +7,7 @@ package goserv
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"unicode"
@@ -55,6 +56,30 @@ type path struct {
 	matcher
 	params *regexp.Regexp
 	names  []string
+	parts  []pathPart
+
+	// Group indices of unnamed wildcards.
+	captures []int
+
+	// Constraints of parameters using the "<name>" syntax.
+	constraints map[string]*ParamConstraint
+}
+
+type pathPartKind int
+
+const (
+	literalPart pathPartKind = iota
+	paramPart
+	wildcardPart
+)
+
+// A pathPart is a single component of a parsed path, used to
+// build concrete URLs from a path.
+type pathPart struct {
+	kind     pathPartKind
+	value    string         // Literal text, parameter or wildcard name
+	rx       *regexp.Regexp // Validates parameter values
+	optional bool
 }
 
 func (p *path) ContainsParams() bool {
@@ -65,19 +90,46 @@ func (p *path) Params() []string {
 	return p.names
 }
 
-func (p *path) FillParams(path string, params params) {
-	if !p.ContainsParams() {
-		return
+// PrefixLength returns the length of the prefix of path matched by
+// the path or -1 if it doesn't match.
+func (p *path) PrefixLength(path string) int {
+	switch m := p.matcher.(type) {
+	case *allMatcher:
+		return 0
+	case *stringPrefixMatcher:
+		if !m.Match(path) {
+			return -1
+		}
+
+		return len(m.path)
+	case *regexpMatcher:
+		if loc := m.rx.FindStringIndex(path); loc != nil {
+			return loc[1]
+		}
+	}
+
+	return -1
+}
+
+func (p *path) ContainsCaptures() bool {
+	return len(p.captures) > 0
+}
+
+// FillParams stores the values of all named parameters and wildcards in params
+// and returns the values captured by unnamed wildcards in order of appearance.
+func (p *path) FillParams(path string, params params) []string {
+	if !p.ContainsParams() && !p.ContainsCaptures() {
+		return nil
 	}
 
-	matches := p.params.FindAllStringSubmatch(path, -1)
+	matches := p.params.FindStringSubmatch(path)
 	if len(matches) == 0 {
-		return
+		return nil
 	}
 
 	// Iterate group matches only
 	names := p.params.SubexpNames()[1:]
-	for index, value := range matches[0][1:] {
+	for index, value := range matches[1:] {
 		name := names[index]
 
 		// Skip unnamed groups
@@ -87,6 +139,60 @@ func (p *path) FillParams(path string, params params) {
 
 		params[name] = value
 	}
+
+	if !p.ContainsCaptures() {
+		return nil
+	}
+
+	captures := make([]string, len(p.captures))
+	for index, group := range p.captures {
+		captures[index] = matches[group]
+	}
+
+	return captures
+}
+
+// Build returns a concrete URL path by replacing all parameters and named
+// wildcards with the given values. Optional literals and unnamed wildcards
+// are omitted.
+//
+// An error is returned if a value for a required parameter is missing
+// or if a value doesn't match the parameter's pattern.
+func (p *path) Build(values params) (string, error) {
+	var buf bytes.Buffer
+
+	for _, part := range p.parts {
+		switch part.kind {
+		case literalPart:
+			if !part.optional {
+				buf.WriteString(part.value)
+			}
+		case paramPart:
+			value, ok := values[part.value]
+			if !ok {
+				return "", fmt.Errorf("missing value for parameter %q", part.value)
+			}
+
+			if !part.rx.MatchString(value) {
+				return "", fmt.Errorf("value %q does not match pattern %q of parameter %q", value, part.rx, part.value)
+			}
+
+			buf.WriteString((&url.URL{Path: value}).EscapedPath())
+		case wildcardPart:
+			if len(part.value) == 0 {
+				break
+			}
+
+			value, ok := values[part.value]
+			if !ok {
+				return "", fmt.Errorf("missing value for wildcard %q", part.value)
+			}
+
+			buf.WriteString((&url.URL{Path: value}).EscapedPath())
+		}
+	}
+
+	return buf.String(), nil
 }
 
 type runeStream struct {
@@ -136,15 +242,19 @@ type pathParser struct {
 	p      *runeStream
 	rxBuf  bytes.Buffer
 	pBuf   bytes.Buffer
-	simple bool // Contains no regexp expressions
+	parts  []pathPart
+	names  []string // Names of parameters and named wildcards
+	simple bool     // Contains no regexp expressions
+
+	captures []int
+
+	constraints map[string]*ParamConstraint
 }
 
 func (p *pathParser) Parse(pattern string, strict, prefix bool) (*path, error) {
 	p.Reset()
 	p.p = &runeStream{data: []rune(pattern)}
 
-	var paramNames []string
-
 	// Write start.
 	p.rxBuf.WriteByte('^')
 
@@ -170,7 +280,7 @@ func (p *pathParser) Parse(pattern string, strict, prefix bool) (*path, error) {
 		case '/':
 			p.startPart(r)
 		case '*':
-			p.wildcard()
+			err = p.wildcard()
 		case '(':
 			err = p.group()
 		case ')':
@@ -178,18 +288,12 @@ func (p *pathParser) Parse(pattern string, strict, prefix bool) (*path, error) {
 		case '?':
 			p.simple = false
 			p.flushPart()
+			p.optionalPart()
 			_, err = p.rxBuf.WriteRune(r)
 		case ':':
 			p.simple = false
 
-			var name string
-			name, err = p.param()
-
-			if err != nil {
-				break
-			}
-
-			paramNames = append(paramNames, name)
+			err = p.param()
 		default:
 			_, err = p.pBuf.WriteRune(r)
 		}
@@ -204,15 +308,17 @@ func (p *pathParser) Parse(pattern string, strict, prefix bool) (*path, error) {
 
 	// Check all matcher
 	if p.rxBuf.String() == "^/(.*)" {
-		return &path{matcher: &allMatcher{}}, nil
+		return &path{matcher: &allMatcher{}, parts: p.parts}, nil
 	}
 
 	if p.simple {
+		parts := []pathPart{{kind: literalPart, value: p.p.String()}}
+
 		if prefix {
-			return &path{matcher: &stringPrefixMatcher{p.p.String()}}, nil
+			return &path{matcher: &stringPrefixMatcher{p.p.String()}, parts: parts}, nil
 		}
 
-		return &path{matcher: &stringMatcher{p.p.String(), strict}}, nil
+		return &path{matcher: &stringMatcher{p.p.String(), strict}, parts: parts}, nil
 	}
 
 	if !strict && !prefix {
@@ -232,12 +338,23 @@ func (p *pathParser) Parse(pattern string, strict, prefix bool) (*path, error) {
 		return nil, err
 	}
 
-	return &path{&regexpMatcher{regexpPattern}, regexpPattern, paramNames}, nil
+	return &path{
+		matcher:     &regexpMatcher{regexpPattern},
+		params:      regexpPattern,
+		names:       p.names,
+		parts:       p.parts,
+		captures:    p.captures,
+		constraints: p.constraints,
+	}, nil
 }
 
 func (p *pathParser) Reset() {
 	p.rxBuf.Reset()
 	p.pBuf.Reset()
+	p.parts = nil
+	p.names = nil
+	p.captures = nil
+	p.constraints = nil
 	p.simple = true
 }
 
@@ -248,18 +365,131 @@ func (p *pathParser) flushPart() {
 
 	safePattern := regexp.QuoteMeta(p.pBuf.String())
 	p.rxBuf.WriteString(safePattern)
+	p.addPart(pathPart{kind: literalPart, value: p.pBuf.String()})
 	p.pBuf.Reset()
 }
 
+func (p *pathParser) addPart(part pathPart) {
+	p.parts = append(p.parts, part)
+}
+
+// optionalPart marks the last part as optional. For literals only the last
+// rune becomes optional, since a "?" only applies to the preceding rune.
+func (p *pathParser) optionalPart() {
+	if len(p.parts) == 0 {
+		return
+	}
+
+	last := &p.parts[len(p.parts)-1]
+
+	if last.kind != literalPart {
+		return
+	}
+
+	runes := []rune(last.value)
+	last.value = string(runes[:len(runes)-1])
+	p.addPart(pathPart{kind: literalPart, value: string(runes[len(runes)-1:]), optional: true})
+}
+
 func (p *pathParser) startPart(r rune) {
 	p.flushPart()
 	p.pBuf.WriteRune(r)
 }
 
-func (p *pathParser) wildcard() {
+// addName records the name of a parameter or named wildcard. Names must
+// be unique within a path.
+func (p *pathParser) addName(name string) error {
+	for _, n := range p.names {
+		if n == name {
+			return p.p.Err("duplicate parameter name '" + name + "'")
+		}
+	}
+
+	p.names = append(p.names, name)
+	return nil
+}
+
+// wildcard writes a named wildcard, if the asterisk starts a segment and is
+// followed by a name spanning the rest of the segment, e.g. "/*filepath".
+// Otherwise an unnamed wildcard is written, whose value is only available
+// by its position.
+func (p *pathParser) wildcard() error {
+	segmentStart := p.pBuf.String() == "/"
+
 	p.flushPart()
 	p.simple = false
+
+	name, err := p.wildcardName(segmentStart)
+	if err != nil {
+		return err
+	}
+
+	if len(name) > 0 {
+		if err := p.addName(name); err != nil {
+			return err
+		}
+
+		p.rxBuf.WriteString(fmt.Sprintf("(?P<%s>.*)", name))
+		p.addPart(pathPart{kind: wildcardPart, value: name})
+		return nil
+	}
+
+	// The index of the wildcard's group is the number of
+	// groups written so far plus one.
+	rx, err := regexp.Compile(p.rxBuf.String())
+	if err != nil {
+		return err
+	}
+
+	p.captures = append(p.captures, rx.NumSubexp()+1)
 	p.rxBuf.WriteString("(.*)")
+	p.addPart(pathPart{kind: wildcardPart})
+
+	return nil
+}
+
+// wildcardName reads the name of a wildcard. If the runes following the
+// asterisk don't form a name spanning the rest of the segment, nothing is
+// consumed and "" is returned.
+//
+// Names consist of ASCII letters, digits and "_". Other alphanumeric runes
+// and "-" can't be part of a name, but would otherwise form one, e.g.
+// "*file-path", which is reported as error.
+func (p *pathParser) wildcardName(segmentStart bool) (string, error) {
+	if !segmentStart {
+		return "", nil
+	}
+
+	start := p.p.idx
+
+	var name bytes.Buffer
+	var invalid rune
+
+	for !p.p.AtEnd() {
+		r := p.p.Next()
+
+		if r == '/' {
+			p.p.Back()
+			break
+		}
+
+		if !isAlphaNumDash(r) {
+			p.p.idx = start
+			return "", nil
+		}
+
+		if invalid == 0 && !isNameRune(r) {
+			invalid = r
+		}
+
+		name.WriteRune(r)
+	}
+
+	if invalid != 0 {
+		return "", p.p.Err("invalid rune '" + string(invalid) + "' in wildcard name")
+	}
+
+	return name.String(), nil
 }
 
 func (p *pathParser) group() error {
@@ -302,8 +532,10 @@ Loop:
 
 	if quote {
 		part = fmt.Sprintf("(%s)", part)
+		p.addPart(pathPart{kind: literalPart, value: part})
 		part = regexp.QuoteMeta(part)
 	} else {
+		p.addPart(pathPart{kind: literalPart, value: part, optional: true})
 		part = fmt.Sprintf("(%s)?", regexp.QuoteMeta(part))
 		p.simple = false
 	}
@@ -314,7 +546,7 @@ Loop:
 	return nil
 }
 
-func (p *pathParser) param() (string, error) {
+func (p *pathParser) param() error {
 	var name bytes.Buffer
 	var pattern bytes.Buffer
 
@@ -327,13 +559,25 @@ Loop:
 			name.WriteRune(r)
 		case r == '(':
 			if name.Len() == 0 {
-				return "", p.p.Err("missing parameter name")
+				return p.p.Err("missing parameter name")
 			}
 
 			var err error
 			pattern, err = p.paramPattern()
 			if err != nil {
-				return "", err
+				return err
+			}
+
+			break Loop
+		case r == '<':
+			if name.Len() == 0 {
+				return p.p.Err("missing parameter name")
+			}
+
+			var err error
+			pattern, err = p.paramConstraint(name.String())
+			if err != nil {
+				return err
 			}
 
 			break Loop
@@ -341,7 +585,7 @@ Loop:
 			p.p.Back()
 			break Loop
 		default:
-			return "", p.p.Err("invalid rune '" + string(r) + "'")
+			return p.p.Err("invalid rune '" + string(r) + "'")
 		}
 	}
 
@@ -353,10 +597,53 @@ Loop:
 
 	part := fmt.Sprintf("(?P<%s>%s)", name.String(), pattern.String())
 
+	rx, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern.String()))
+	if err != nil {
+		return err
+	}
+
+	if err := p.addName(name.String()); err != nil {
+		return err
+	}
+
 	p.flushPart()
 	p.rxBuf.WriteString(part)
+	p.addPart(pathPart{kind: paramPart, value: name.String(), rx: rx})
 
-	return name.String(), nil
+	return nil
+}
+
+// paramConstraint reads the name of a constraint until the closing '>'
+// and returns the constraint's pattern.
+func (p *pathParser) paramConstraint(param string) (bytes.Buffer, error) {
+	var name, pattern bytes.Buffer
+
+	for {
+		if p.p.AtEnd() {
+			return pattern, p.p.Err("unmatched '<'")
+		}
+
+		r := p.p.Next()
+		if r == '>' {
+			break
+		}
+
+		name.WriteRune(r)
+	}
+
+	constraint := lookupConstraint(name.String())
+	if constraint == nil {
+		return pattern, p.p.Err("unknown constraint '" + name.String() + "'")
+	}
+
+	if p.constraints == nil {
+		p.constraints = make(map[string]*ParamConstraint)
+	}
+
+	p.constraints[param] = constraint
+	pattern.WriteString(constraint.Pattern)
+
+	return pattern, nil
 }
 
 func (p *pathParser) paramPattern() (bytes.Buffer, error) {
@@ -402,6 +689,11 @@ func isAlphaNum(r rune) bool {
 	return unicode.In(r, unicode.Digit, unicode.Letter)
 }
 
+// Returns true if r can be part of a capture group name.
+func isNameRune(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
 func isAlphaNumDash(r rune) bool {
 	return isAlphaNum(r) || r == '_' || r == '-'
 }
@@ -93,6 +93,50 @@ func TestPathComponents(t *testing.T) {
 			TestPath: "/khi",
 			Match:    false,
 		},
+		{
+			Path:     "/files/*filepath",
+			TestPath: "/files/css/main.css",
+			Params:   params{"filepath": "css/main.css"},
+			Match:    true,
+			Regexp:   "^/files/(?P<filepath>.*)/?$",
+		},
+		{
+			Path:     "/:user/*path/edit",
+			TestPath: "/joe/a/b/edit",
+			Params:   params{"user": "joe", "path": "a/b"},
+			Match:    true,
+		},
+		{
+			Path:     "/my*path",
+			TestPath: "/myfunnypath",
+			Match:    true,
+			Regexp:   "^/my(.*)path/?$",
+		},
+		{
+			Path:     "/*.html",
+			TestPath: "/index.html",
+			Match:    true,
+			Regexp:   "^/(.*)\\.html/?$",
+		},
+		{
+			// Letters after an asterisk starting a segment form a name, so
+			// the pattern matches all paths instead of those ending in "html".
+			Path:     "/x/*html",
+			TestPath: "/x/foo",
+			Params:   params{"html": "foo"},
+			Match:    true,
+		},
+		{
+			Path:     "/x/:rest(.*html)",
+			TestPath: "/x/foo",
+			Match:    false,
+		},
+		{
+			Path:     "/x/:rest(.*html)",
+			TestPath: "/x/a/b.html",
+			Params:   params{"rest": "a/b.html"},
+			Match:    true,
+		},
 
 		// Groups
 		{
@@ -238,6 +282,18 @@ func TestPathComponents(t *testing.T) {
 			Path: "/abc(:)",
 			Err:  fmt.Errorf("Error at index 6, invalid rune ')'"),
 		},
+		{
+			Path: "/:id/:id",
+			Err:  fmt.Errorf("Error at index 7, duplicate parameter name 'id'"),
+		},
+		{
+			Path: "/:path/*path",
+			Err:  fmt.Errorf("Error at index 11, duplicate parameter name 'path'"),
+		},
+		{
+			Path: "/files/*file-path",
+			Err:  fmt.Errorf("Error at index 16, invalid rune '-' in wildcard name"),
+		},
 	}
 
 	for _, test := range tests {
@@ -311,3 +367,146 @@ func TestPathComponents(t *testing.T) {
 		}
 	}
 }
+
+func TestPathCaptures(t *testing.T) {
+	tests := []struct {
+		Path, TestPath string
+		Captures       []string
+		Params         params
+	}{
+		{Path: "/a/*/c", TestPath: "/a/b/c", Captures: []string{"b"}},
+		{Path: "/a*/:id/*", TestPath: "/abc/12/x", Captures: []string{"bc", "x"}, Params: params{"id": "12"}},
+		{Path: "/:id(a(b)?)/*", TestPath: "/ab/x", Captures: []string{"x"}, Params: params{"id": "ab"}},
+		{Path: "/a(bc)?/*/*rest", TestPath: "/abc/x/y", Captures: []string{"x"}, Params: params{"rest": "y"}},
+		{Path: "/files/*filepath", TestPath: "/files/a", Params: params{"filepath": "a"}},
+	}
+
+	for index, test := range tests {
+		path, err := parsePath(test.Path, false, false)
+		if err != nil {
+			t.Errorf("Unexpected parser error: %s (no. %d)", err, index)
+			continue
+		}
+
+		values := make(params)
+		captures := path.FillParams(test.TestPath, values)
+
+		if !reflect.DeepEqual(captures, test.Captures) {
+			t.Errorf("Wrong captures: %v != %v (no. %d)", captures, test.Captures, index)
+		}
+
+		for name, value := range test.Params {
+			if values[name] != value {
+				t.Errorf("Wrong value for parameter '%s': %s != %s (no. %d)", name, values[name], value, index)
+			}
+		}
+	}
+}
+
+func TestPathBuild(t *testing.T) {
+	tests := []struct {
+		Path   string
+		Values params
+		URL    string
+		Err    error
+	}{
+		{Path: "/", URL: "/"},
+		{Path: "/abc/def", URL: "/abc/def"},
+		{Path: "/ab?c", URL: "/ac"},
+		{Path: "/abc/(def)?/ghi", URL: "/abc/ghi"},
+		{Path: "/abc*", URL: "/abc"},
+		{Path: "/:id", Values: params{"id": "tab"}, URL: "/tab"},
+		{Path: "/:id1/abc/:id2", Values: params{"id1": "tab", "id2": "akad"}, URL: "/tab/abc/akad"},
+		{Path: "/:id1(\\d+)", Values: params{"id1": "12345"}, URL: "/12345"},
+		{Path: "/:name", Values: params{"name": "a b"}, URL: "/a%20b"},
+		{Path: "/files/*filepath", Values: params{"filepath": "css/main.css"}, URL: "/files/css/main.css"},
+
+		// NEGATIVE TESTS //
+		{
+			Path: "/:id",
+			Err:  fmt.Errorf("missing value for parameter \"id\""),
+		},
+		{
+			Path:   "/:id1(\\d+)",
+			Values: params{"id1": "abc"},
+			Err:    fmt.Errorf("value \"abc\" does not match pattern \"^(?:\\\\d+)$\" of parameter \"id1\""),
+		},
+		{
+			Path: "/files/*filepath",
+			Err:  fmt.Errorf("missing value for wildcard \"filepath\""),
+		},
+	}
+
+	for _, test := range tests {
+		path, err := parsePath(test.Path, false, false)
+		if err != nil {
+			t.Errorf("Unexpected parser error: %s", err)
+			continue
+		}
+
+		url, err := path.Build(test.Values)
+
+		if test.Err != nil {
+			if err == nil {
+				t.Errorf("Expected build error for %s", test.Path)
+			} else if m1, m2 := test.Err.Error(), err.Error(); m1 != m2 {
+				t.Errorf("Wrong error message, expected: %s, actual: %s", m1, m2)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected build error: %s", err)
+			continue
+		}
+
+		if url != test.URL {
+			t.Errorf("Wrong URL for %s, expected: %s, actual: %s", test.Path, test.URL, url)
+		}
+	}
+}
+
+func TestPathConstraints(t *testing.T) {
+	tests := []struct {
+		Path, TestPath string
+		Match          bool
+		Err            error
+	}{
+		{Path: "/:id<int>", TestPath: "/123", Match: true},
+		{Path: "/:id<int>", TestPath: "/-123", Match: true},
+		{Path: "/:id<int>", TestPath: "/abc", Match: false},
+		{Path: "/:slug<slug>", TestPath: "/hello-world", Match: true},
+		{Path: "/:slug<slug>", TestPath: "/Hello_World", Match: false},
+		{Path: "/:id<uuid>/edit", TestPath: "/123e4567-e89b-12d3-a456-426614174000/edit", Match: true},
+		{Path: "/:id<uuid>/edit", TestPath: "/123e4567/edit", Match: false},
+		{Path: "/:day<date>", TestPath: "/2016-05-12", Match: true},
+
+		{Path: "/:id<unknown>", Err: fmt.Errorf("Error at index 12, unknown constraint 'unknown'")},
+		{Path: "/:id<int", Err: fmt.Errorf("Error at index 7, unmatched '<'")},
+		{Path: "/:<int>", Err: fmt.Errorf("Error at index 2, missing parameter name")},
+	}
+
+	for _, test := range tests {
+		path, err := parsePath(test.Path, false, false)
+
+		if test.Err != nil {
+			if err == nil {
+				t.Errorf("Expected parser error for %s", test.Path)
+			} else if m1, m2 := test.Err.Error(), err.Error(); m1 != m2 {
+				t.Errorf("Wrong error message, expected: %s, actual: %s", m1, m2)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected parser error: %s", err)
+			continue
+		}
+
+		if res := path.Match(test.TestPath); res != test.Match {
+			t.Errorf("Path match error: %s == %s, expected: %t, actual: %t", test.Path, test.TestPath, test.Match, res)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+)
+
+// A Problem can be implemented by errors to customize the problem details
+// rendered by the ProblemErrorHandler.
+type Problem interface {
+	// ProblemMembers returns additional members of the problem details object.
+	// The members may also override the standard members "type", "title",
+	// "detail" and "instance".
+	ProblemMembers() map[string]interface{}
+}
+
+// ProblemErrorHandler is an ErrorHandler rendering errors as problem
+// details as specified by RFC 7807.
+//
+// The response format is negotiated using the request's Accept header. Besides the
+// "application/problem+json" format the error can also be rendered as plain
+// text or HTML. If the error implements Problem, or wraps an error implementing it,
+// the additional members are added to the JSON object.
+var ProblemErrorHandler = func(w http.ResponseWriter, r *http.Request, err *ContextError) {
+	members := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    http.StatusText(err.Code),
+		"status":   err.Code,
+		"detail":   err.PublicMessage(),
+		"instance": r.URL.Path,
+	}
+
+	var problem Problem
+	if errors.As(err.Err, &problem) {
+		for name, value := range problem.ProblemMembers() {
+			members[name] = value
+		}
+	}
+
+	// The status is always the one of the response.
+	members["status"] = err.Code
+
+	contentType := negotiateContentType(r.Header.Get("Accept"), []string{
+		"application/problem+json", "application/json", "text/html", "text/plain",
+	})
+
+	err.ApplyHeaders(w.Header())
+
+	title := fmt.Sprint(members["title"])
+	detail := fmt.Sprint(members["detail"])
+
+	switch contentType {
+	case "text/html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(err.Code)
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head><title>%d %s</title></head>\n<body>\n<h1>%d %s</h1>\n<p>%s</p>\n</body>\n</html>\n",
+			err.Code, html.EscapeString(title), err.Code, html.EscapeString(title), html.EscapeString(detail))
+	case "text/plain":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(err.Code)
+		fmt.Fprintf(w, "%d %s: %s\n", err.Code, title, detail)
+	default:
+		if contentType != "application/json" {
+			contentType = "application/problem+json"
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(err.Code)
+		json.NewEncoder(w).Encode(members)
+	}
+}
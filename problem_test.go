@@ -0,0 +1,135 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type outOfCreditError struct{ balance int }
+
+func (o *outOfCreditError) Error() string { return "not enough credit" }
+
+func (o *outOfCreditError) ProblemMembers() map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "https://example.com/probs/out-of-credit",
+		"title":   "You do not have enough credit.",
+		"balance": o.balance,
+		"status":  200, // Must be ignored
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"application/problem+json", "application/json", "text/html", "text/plain"}
+
+	tests := []struct {
+		accept, contentType string
+	}{
+		{"", "application/problem+json"},
+		{"*/*", "application/problem+json"},
+		{"application/json", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{"text/*;q=0.5, text/plain", "text/plain"},
+		{"text/*", "text/html"},
+		{"TEXT/PLAIN; charset=utf-8", "text/plain"},
+		{"application/json;q=0.1, text/plain;q=0.2", "text/plain"},
+		{"*/*;q=0.1, application/json;q=0", "application/problem+json"},
+		{"image/png", ""},
+	}
+
+	for _, test := range tests {
+		if contentType := negotiateContentType(test.accept, offers); contentType != test.contentType {
+			t.Errorf("Wrong content type for %q, expected: %q, actual: %q", test.accept, test.contentType, contentType)
+		}
+	}
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	tests := []struct {
+		accept      string
+		err         error
+		contentType string
+		body        string
+		members     map[string]interface{}
+	}{
+		{"", errors.New("no such user"), "application/problem+json", "", map[string]interface{}{
+			"type":     "about:blank",
+			"title":    "Not Found",
+			"status":   float64(404),
+			"detail":   "no such user",
+			"instance": "/users/42",
+		}},
+		{"application/json", fmt.Errorf("wrapped: %w", &outOfCreditError{30}), "application/json", "", map[string]interface{}{
+			"type":    "https://example.com/probs/out-of-credit",
+			"title":   "You do not have enough credit.",
+			"status":  float64(404),
+			"detail":  "wrapped: not enough credit",
+			"balance": float64(30),
+		}},
+		{"text/plain", errors.New("100% <missing>"), "text/plain; charset=utf-8", "404 Not Found: 100% <missing>\n", nil},
+		{"text/html", errors.New("100% <missing>"), "text/html; charset=utf-8", "<p>100% &lt;missing&gt;</p>", nil},
+	}
+
+	for index, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		r.Header.Set("Accept", test.accept)
+
+		ProblemErrorHandler(w, r, NotFound(test.err))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Wrong status code: %d != %d (no. %d)", w.Code, http.StatusNotFound, index)
+		}
+
+		if contentType := w.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("Wrong content type: %q != %q (no. %d)", contentType, test.contentType, index)
+		}
+
+		if len(test.body) > 0 && !strings.Contains(w.Body.String(), test.body) {
+			t.Errorf("Body %q doesn't contain %q (no. %d)", w.Body.String(), test.body, index)
+		}
+
+		if test.members == nil {
+			continue
+		}
+
+		var members map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &members); err != nil {
+			t.Errorf("Invalid JSON: %v (no. %d)", err, index)
+			continue
+		}
+
+		for name, value := range test.members {
+			if members[name] != value {
+				t.Errorf("Wrong member %q: %v != %v (no. %d)", name, members[name], value, index)
+			}
+		}
+	}
+}
+
+func TestStdErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	StdErrorHandler(w, r, BadRequest(errors.New("100% failed")))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Wrong status code: %d != %d", w.Code, http.StatusBadRequest)
+	}
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Wrong content type: %q", contentType)
+	}
+
+	if body := w.Body.String(); body != "100% failed" {
+		t.Errorf("Wrong body: %q != %q", body, "100% failed")
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	gopath "path"
+	"strings"
+	"sync"
+)
+
+// ErrNoRenderer is returned by Render if the Server has no Renderer
+// or the request wasn't dispatched by a Server.
+var ErrNoRenderer = errors.New("no renderer")
+
+// A Renderer renders named templates, see Server.Renderer.
+type Renderer interface {
+	// Render writes the template with the given name using data to w.
+	Render(w io.Writer, name string, data interface{}) error
+}
+
+// Render renders the template with the given name using the Renderer of the Server
+// processing the request and writes it with status "200 OK". See RenderStatus.
+func Render(w http.ResponseWriter, r *http.Request, name string, data interface{}) error {
+	return RenderStatus(w, r, http.StatusOK, name, data)
+}
+
+// RenderStatus renders the template with the given name using the Renderer of the
+// Server processing the request and writes it with the specified status code.
+//
+// The template is rendered completely before anything is written, so that no partial
+// responses are sent if rendering fails. If no Content-Type is set, it is set to
+// "text/html; charset=utf-8". ErrNoRenderer is returned if the Server has no Renderer
+// or the request wasn't dispatched by a Server.
+func RenderStatus(w http.ResponseWriter, r *http.Request, code int, name string, data interface{}) error {
+	ctx := Context(r)
+	if ctx == nil || ctx.renderer == nil {
+		return ErrNoRenderer
+	}
+
+	var buf bytes.Buffer
+	if err := ctx.renderer.Render(&buf, name, data); err != nil {
+		return err
+	}
+
+	if len(w.Header().Get("Content-Type")) == 0 {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	w.WriteHeader(code)
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// TemplateOptions configure a TemplateRenderer.
+type TemplateOptions struct {
+	// Directory containing the templates, used if FS is nil.
+	Root string
+
+	// File system containing the templates.
+	FS fs.FS
+
+	// Extension of template files. Defaults to ".html".
+	Extension string
+
+	// Name of the layout template wrapping all pages, e.g. "layouts/main", or "" for
+	// no layout. The layout includes the page using {{template "content" .}}.
+	Layout string
+
+	// Directory containing the partials, which are available in all templates by
+	// their name, e.g. {{template "partials/header" .}}. Defaults to "partials".
+	Partials string
+
+	// Functions available in all templates.
+	Funcs template.FuncMap
+
+	// Parses the templates again before each rendering, e.g. during development.
+	Reload bool
+}
+
+// A TemplateRenderer is a Renderer using html/template.
+//
+// Templates are named by their path relative to the template root without extension,
+// e.g. "users/show" for the file "users/show.html". All templates except the layouts,
+// located in the "layouts" directory, and the partials are pages, which can be
+// rendered. If a layout is configured the page's content becomes the "content"
+// template, which is included by the layout. Pages can define additional templates,
+// e.g. a title, used by the layout with {{block "title" .}}Default{{end}}.
+type TemplateRenderer struct {
+	opts  TemplateOptions
+	mutex sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewTemplateRenderer returns a new TemplateRenderer and parses all templates.
+// Parse errors are returned.
+func NewTemplateRenderer(opts TemplateOptions) (*TemplateRenderer, error) {
+	if opts.FS == nil {
+		opts.FS = os.DirFS(opts.Root)
+	}
+
+	if len(opts.Extension) == 0 {
+		opts.Extension = ".html"
+	}
+
+	if len(opts.Partials) == 0 {
+		opts.Partials = "partials"
+	}
+
+	t := &TemplateRenderer{opts: opts}
+	if err := t.Load(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Load parses all templates again. On errors the previously
+// loaded templates are kept.
+func (t *TemplateRenderer) Load() error {
+	layouts, partials, pages := make(map[string]string), make(map[string]string), make(map[string]string)
+
+	err := fs.WalkDir(t.opts.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || gopath.Ext(p) != t.opts.Extension {
+			return nil
+		}
+
+		data, err := fs.ReadFile(t.opts.FS, p)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(p, t.opts.Extension)
+
+		switch {
+		case strings.HasPrefix(name, "layouts/"):
+			layouts[name] = string(data)
+		case strings.HasPrefix(name, t.opts.Partials+"/"):
+			partials[name] = string(data)
+		default:
+			pages[name] = string(data)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	base := template.New("").Funcs(t.opts.Funcs)
+
+	for name, text := range partials {
+		if _, err := base.New(name).Parse(text); err != nil {
+			return err
+		}
+	}
+
+	if len(t.opts.Layout) > 0 {
+		text, ok := layouts[t.opts.Layout]
+		if !ok {
+			return fmt.Errorf("layout %q not found", t.opts.Layout)
+		}
+
+		if _, err := base.New(t.opts.Layout).Parse(text); err != nil {
+			return err
+		}
+	}
+
+	compiled := make(map[string]*template.Template, len(pages))
+
+	for name, text := range pages {
+		page, err := base.Clone()
+		if err != nil {
+			return err
+		}
+
+		tmplName := name
+		if len(t.opts.Layout) > 0 {
+			tmplName = "content"
+		}
+
+		if _, err := page.New(tmplName).Parse(text); err != nil {
+			return err
+		}
+
+		if len(t.opts.Layout) > 0 {
+			page = page.Lookup(t.opts.Layout)
+		} else {
+			page = page.Lookup(name)
+		}
+
+		compiled[name] = page
+	}
+
+	t.mutex.Lock()
+	t.pages = compiled
+	t.mutex.Unlock()
+
+	return nil
+}
+
+// Render writes the page with the given name to w. If the TemplateRenderer
+// was created with the Reload option all templates are parsed first.
+func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}) error {
+	if t.opts.Reload {
+		if err := t.Load(); err != nil {
+			return err
+		}
+	}
+
+	t.mutex.RLock()
+	page, ok := t.pages[name]
+	t.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("template %q not found", name)
+	}
+
+	return page.Execute(w, data)
+}
+
+// Templates sets the Server's Renderer to a new TemplateRenderer.
+//
+// In addition to the functions in opts.Funcs the templates can use the "url"
+// function building the URL of a named Route, see Router.URL:
+//
+//	<a href="{{url "user" "user_id" .ID}}">Profile</a>
+func (s *Server) Templates(opts TemplateOptions) error {
+	funcs := template.FuncMap{
+		"url": func(name string, pairs ...interface{}) (string, error) {
+			values := make([]string, len(pairs))
+			for i, v := range pairs {
+				values[i] = fmt.Sprint(v)
+			}
+
+			return s.URL(name, values...)
+		},
+	}
+
+	for name, fn := range opts.Funcs {
+		funcs[name] = fn
+	}
+
+	opts.Funcs = funcs
+
+	renderer, err := NewTemplateRenderer(opts)
+	if err != nil {
+		return err
+	}
+
+	s.Renderer = renderer
+	return nil
+}
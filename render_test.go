@@ -0,0 +1,125 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/main.html":   {Data: []byte(`<title>{{block "title" .}}Default{{end}}</title>{{template "partials/nav" .}}<main>{{template "content" .}}</main>`)},
+		"partials/nav.html":   {Data: []byte(`<nav>{{upper "nav"}}</nav>`)},
+		"index.html":          {Data: []byte(`Hello {{.}}`)},
+		"users/show.html":     {Data: []byte(`{{define "title"}}User{{end}}<a href="{{url "user" "user_id" .}}">{{.}}</a>`)},
+		"README.md":           {Data: []byte(`ignored`)},
+		"layouts/simple.html": {Data: []byte(`{{template "content" .}}`)},
+	}
+
+	server := NewServer()
+	server.Route("/users/:user_id(\\d+)").Name("user")
+
+	err := server.Templates(TemplateOptions{
+		FS:     fsys,
+		Layout: "layouts/main",
+		Funcs:  template.FuncMap{"upper": strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	server.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := Render(w, r, "index", "<World>"); err != nil {
+			t.Errorf("Unexpected render error: %v", err)
+		}
+	})
+	server.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if err := RenderStatus(w, r, http.StatusCreated, "users/show", Context(r).Param("id")); err != nil {
+			t.Errorf("Unexpected render error: %v", err)
+		}
+	})
+	server.Get("/missing", func(w http.ResponseWriter, r *http.Request) {
+		if err := Render(w, r, "missing", nil); err == nil {
+			t.Error("Expected error for missing template")
+		}
+	})
+
+	tests := []struct {
+		Path   string
+		Status int
+		Body   string
+	}{
+		{"/", http.StatusOK, `<title>Default</title><nav>NAV</nav><main>Hello &lt;World&gt;</main>`},
+		{"/users/12", http.StatusCreated, `<title>User</title><nav>NAV</nav><main><a href="/users/12">12</a></main>`},
+		{"/missing", http.StatusNotFound, ""},
+	}
+
+	for index, test := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.Path, nil))
+
+		if w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+
+		if len(test.Body) > 0 && w.Body.String() != test.Body {
+			t.Errorf("Wrong body: %s != %s (no. %d)", w.Body.String(), test.Body, index)
+		}
+
+		if len(test.Body) > 0 && w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Errorf("Wrong Content-Type: %s (no. %d)", w.Header().Get("Content-Type"), index)
+		}
+	}
+}
+
+func TestTemplateRendererReload(t *testing.T) {
+	fsys := fstest.MapFS{"page.html": {Data: []byte("v1")}}
+
+	renderer, err := NewTemplateRenderer(TemplateOptions{FS: fsys, Reload: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf strings.Builder
+	renderer.Render(&buf, "page", nil)
+
+	fsys["page.html"] = &fstest.MapFile{Data: []byte("v2")}
+	renderer.Render(&buf, "page", nil)
+
+	if buf.String() != "v1v2" {
+		t.Errorf("Templates not reloaded: %s", buf.String())
+	}
+
+	if _, err := NewTemplateRenderer(TemplateOptions{FS: fsys, Layout: "layouts/missing"}); err == nil {
+		t.Error("Expected error for missing layout")
+	}
+}
+
+func TestRenderWithoutRenderer(t *testing.T) {
+	server := NewServer()
+
+	var err error
+	server.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		err = Render(w, r, "index", nil)
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if err != ErrNoRenderer {
+		t.Errorf("Wrong error: %v != %v", err, ErrNoRenderer)
+	}
+
+	// Requests not dispatched by a Server have no RequestContext.
+	err = Render(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "index", nil)
+
+	if err != ErrNoRenderer {
+		t.Errorf("Wrong error without context: %v != %v", err, ErrNoRenderer)
+	}
+}
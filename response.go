@@ -5,12 +5,47 @@
 package goserv
 
 import (
+	"bufio"
+	"net"
 	"net/http"
 )
 
+// A ResponseWriter is a http.ResponseWriter providing information about the
+// written response. All ResponseWriters passed to handlers by a Server implement
+// this interface as well as all optional interfaces, e.g. http.Flusher, implemented
+// by the ResponseWriter passed to the Server.
+//
+// The ResponseWriter can be obtained using a type assertion:
+//
+//	status := w.(goserv.ResponseWriter).Status()
+type ResponseWriter interface {
+	http.ResponseWriter
+
+	// Status returns the written status code or 0 if no
+	// header was written yet.
+	Status() int
+
+	// Written returns true if the response header was written.
+	Written() bool
+
+	// Size returns the number of body bytes written.
+	Size() int
+
+	// Before registers a function which is invoked right before the
+	// response header is written. At this point the status code is already
+	// available and the header can still be modified. Functions are invoked
+	// in the reverse order they were registered.
+	Before(func(ResponseWriter))
+}
+
 type responseWriter struct {
-	w      http.ResponseWriter
-	status int
+	w        http.ResponseWriter
+	outer    ResponseWriter // Outermost wrapper passed to before hooks
+	status   int
+	size     int
+	discard  bool
+	hijacked bool
+	before   []func(ResponseWriter)
 }
 
 func (r *responseWriter) Header() http.Header {
@@ -22,22 +57,247 @@ func (r *responseWriter) Write(b []byte) (int, error) {
 		r.WriteHeader(http.StatusOK)
 	}
 
-	return r.w.Write(b)
+	if r.discard {
+		return len(b), nil
+	}
+
+	n, err := r.w.Write(b)
+	r.size += n
+
+	return n, err
 }
 
 func (r *responseWriter) WriteHeader(status int) {
+	// Like the http package only the first status is written.
+	if r.Written() {
+		return
+	}
+
+	// Informational responses, e.g. "103 Early Hints", precede the final status.
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		r.w.WriteHeader(status)
+		return
+	}
+
 	r.status = status
+
+	for i := len(r.before) - 1; i >= 0; i-- {
+		r.before[i](r.outer)
+	}
+
 	r.w.WriteHeader(status)
 }
 
 func (r *responseWriter) Written() bool {
-	return r.status != 0
+	return r.status != 0 || r.hijacked
 }
 
-func (r *responseWriter) Code() int {
+func (r *responseWriter) Status() int {
 	return r.status
 }
 
-func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w: w}
+func (r *responseWriter) Size() int {
+	return r.size
+}
+
+func (r *responseWriter) Before(fn func(ResponseWriter)) {
+	r.before = append(r.before, fn)
+}
+
+// Unwrap returns the underlying ResponseWriter, which allows
+// http.ResponseController to access it.
+func (r *responseWriter) Unwrap() http.ResponseWriter {
+	return r.w
+}
+
+// discardBody makes all subsequent writes succeed without writing
+// to the underlying ResponseWriter, e.g. for answering HEAD requests.
+func (r *responseWriter) discardBody() {
+	r.discard = true
+}
+
+func (r *responseWriter) writer() *responseWriter {
+	return r
+}
+
+// The optional interfaces of the underlying ResponseWriter are implemented by
+// separate types, which are combined with the responseWriter depending on the
+// interfaces supported. This way type assertions on the wrapped ResponseWriter
+// behave exactly like type assertions on the underlying ResponseWriter.
+
+type flusher struct{ *responseWriter }
+
+func (f flusher) Flush() {
+	if !f.Written() {
+		f.WriteHeader(http.StatusOK)
+	}
+
+	f.w.(http.Flusher).Flush()
+}
+
+type hijacker struct{ *responseWriter }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.w.(http.Hijacker).Hijack()
+
+	// The connection is taken over, so no more responses must be written.
+	if err == nil {
+		h.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+type pusher struct{ *responseWriter }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.(http.Pusher).Push(target, opts)
+}
+
+type closeNotifier struct{ *responseWriter }
+
+func (c closeNotifier) CloseNotify() <-chan bool {
+	return c.w.(http.CloseNotifier).CloseNotify()
+}
+
+const (
+	flusherFlag = 1 << iota
+	hijackerFlag
+	pusherFlag
+	closeNotifierFlag
+)
+
+func newResponseWriter(w http.ResponseWriter) ResponseWriter {
+	r := &responseWriter{w: w}
+	r.outer = wrapResponseWriter(r)
+	return r.outer
+}
+
+func wrapResponseWriter(r *responseWriter) ResponseWriter {
+	w := r.w
+
+	var flags int
+
+	if _, ok := w.(http.Flusher); ok {
+		flags |= flusherFlag
+	}
+
+	if _, ok := w.(http.Hijacker); ok {
+		flags |= hijackerFlag
+	}
+
+	if _, ok := w.(http.Pusher); ok {
+		flags |= pusherFlag
+	}
+
+	if _, ok := w.(http.CloseNotifier); ok {
+		flags |= closeNotifierFlag
+	}
+
+	f, h, p, c := flusher{r}, hijacker{r}, pusher{r}, closeNotifier{r}
+
+	switch flags {
+	case flusherFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+		}{r, f}
+	case hijackerFlag:
+		return struct {
+			*responseWriter
+			http.Hijacker
+		}{r, h}
+	case flusherFlag | hijackerFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+		}{r, f, h}
+	case pusherFlag:
+		return struct {
+			*responseWriter
+			http.Pusher
+		}{r, p}
+	case flusherFlag | pusherFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+		}{r, f, p}
+	case hijackerFlag | pusherFlag:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+		}{r, h, p}
+	case flusherFlag | hijackerFlag | pusherFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{r, f, h, p}
+	case closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.CloseNotifier
+		}{r, c}
+	case flusherFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.CloseNotifier
+		}{r, f, c}
+	case hijackerFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.CloseNotifier
+		}{r, h, c}
+	case flusherFlag | hijackerFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.CloseNotifier
+		}{r, f, h, c}
+	case pusherFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Pusher
+			http.CloseNotifier
+		}{r, p, c}
+	case flusherFlag | pusherFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Pusher
+			http.CloseNotifier
+		}{r, f, p, c}
+	case hijackerFlag | pusherFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Hijacker
+			http.Pusher
+			http.CloseNotifier
+		}{r, h, p, c}
+	case flusherFlag | hijackerFlag | pusherFlag | closeNotifierFlag:
+		return struct {
+			*responseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			http.CloseNotifier
+		}{r, f, h, p, c}
+	}
+
+	return r
+}
+
+// Returns the responseWriter wrapped by a ResponseWriter
+// created with newResponseWriter.
+func internalWriter(w http.ResponseWriter) *responseWriter {
+	return w.(interface {
+		writer() *responseWriter
+	}).writer()
 }
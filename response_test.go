@@ -0,0 +1,304 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Mock implementations of the optional ResponseWriter interfaces
+// recording their invocations.
+type mockFlusher struct{ h *historyWriter }
+
+func (m mockFlusher) Flush() { m.h.WriteString("flush") }
+
+type mockHijacker struct{ h *historyWriter }
+
+func (m mockHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	m.h.WriteString("hijack")
+	return nil, nil, nil
+}
+
+type mockPusher struct{ h *historyWriter }
+
+func (m mockPusher) Push(string, *http.PushOptions) error {
+	m.h.WriteString("push")
+	return nil
+}
+
+type mockCloseNotifier struct{ h *historyWriter }
+
+func (m mockCloseNotifier) CloseNotify() <-chan bool {
+	m.h.WriteString("close-notify")
+	return nil
+}
+
+// Returns a ResponseWriter implementing the optional interfaces selected by flags.
+func newMockResponseWriter(flags int, h *historyWriter) http.ResponseWriter {
+	var w struct {
+		http.ResponseWriter
+		http.Flusher
+		http.Hijacker
+		http.Pusher
+		http.CloseNotifier
+	}
+
+	w.ResponseWriter = httptest.NewRecorder()
+	w.Flusher = mockFlusher{h}
+	w.Hijacker = mockHijacker{h}
+	w.Pusher = mockPusher{h}
+	w.CloseNotifier = mockCloseNotifier{h}
+
+	// Hide the interfaces not selected by flags.
+	var res http.ResponseWriter = struct{ http.ResponseWriter }{w.ResponseWriter}
+
+	switch flags {
+	case 0:
+	case flusherFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+		}{w.ResponseWriter, w.Flusher}
+	case hijackerFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Hijacker
+		}{w.ResponseWriter, w.Hijacker}
+	case flusherFlag | hijackerFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{w.ResponseWriter, w.Flusher, w.Hijacker}
+	case pusherFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Pusher
+		}{w.ResponseWriter, w.Pusher}
+	case flusherFlag | pusherFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Pusher
+		}{w.ResponseWriter, w.Flusher, w.Pusher}
+	case hijackerFlag | pusherFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Hijacker
+			http.Pusher
+		}{w.ResponseWriter, w.Hijacker, w.Pusher}
+	case flusherFlag | hijackerFlag | pusherFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w.ResponseWriter, w.Flusher, w.Hijacker, w.Pusher}
+	case closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.CloseNotifier
+		}{w.ResponseWriter, w.CloseNotifier}
+	case flusherFlag | closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+			http.CloseNotifier
+		}{w.ResponseWriter, w.Flusher, w.CloseNotifier}
+	case hijackerFlag | closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Hijacker
+			http.CloseNotifier
+		}{w.ResponseWriter, w.Hijacker, w.CloseNotifier}
+	case flusherFlag | hijackerFlag | closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+			http.CloseNotifier
+		}{w.ResponseWriter, w.Flusher, w.Hijacker, w.CloseNotifier}
+	case pusherFlag | closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Pusher
+			http.CloseNotifier
+		}{w.ResponseWriter, w.Pusher, w.CloseNotifier}
+	case flusherFlag | pusherFlag | closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Pusher
+			http.CloseNotifier
+		}{w.ResponseWriter, w.Flusher, w.Pusher, w.CloseNotifier}
+	case hijackerFlag | pusherFlag | closeNotifierFlag:
+		res = struct {
+			http.ResponseWriter
+			http.Hijacker
+			http.Pusher
+			http.CloseNotifier
+		}{w.ResponseWriter, w.Hijacker, w.Pusher, w.CloseNotifier}
+	case flusherFlag | hijackerFlag | pusherFlag | closeNotifierFlag:
+		res = w
+	}
+
+	return res
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	for flags := 0; flags < 16; flags++ {
+		h := &historyWriter{}
+		res := newResponseWriter(newMockResponseWriter(flags, h))
+
+		var expected []string
+
+		if f, ok := res.(http.Flusher); ok != (flags&flusherFlag != 0) {
+			t.Errorf("Wrong http.Flusher support: %t (flags: %04b)", ok, flags)
+		} else if ok {
+			f.Flush()
+			expected = append(expected, "flush")
+		}
+
+		if hj, ok := res.(http.Hijacker); ok != (flags&hijackerFlag != 0) {
+			t.Errorf("Wrong http.Hijacker support: %t (flags: %04b)", ok, flags)
+		} else if ok {
+			hj.Hijack()
+			expected = append(expected, "hijack")
+		}
+
+		if p, ok := res.(http.Pusher); ok != (flags&pusherFlag != 0) {
+			t.Errorf("Wrong http.Pusher support: %t (flags: %04b)", ok, flags)
+		} else if ok {
+			p.Push("/", nil)
+			expected = append(expected, "push")
+		}
+
+		if c, ok := res.(http.CloseNotifier); ok != (flags&closeNotifierFlag != 0) {
+			t.Errorf("Wrong http.CloseNotifier support: %t (flags: %04b)", ok, flags)
+		} else if ok {
+			c.CloseNotify()
+			expected = append(expected, "close-notify")
+		}
+
+		if h.Len() != len(expected) {
+			t.Errorf("Wrong call count %d != %d, %v (flags: %04b)", h.Len(), len(expected), h.writes, flags)
+			continue
+		}
+
+		for index, value := range expected {
+			if h.At(index) != value {
+				t.Errorf("Wrong call at %d: %s != %s (flags: %04b)", index, h.At(index), value, flags)
+			}
+		}
+
+		// Flushing and hijacking must end the processing of handlers.
+		if written := internalWriter(res).Written(); written != (flags&(flusherFlag|hijackerFlag) != 0) {
+			t.Errorf("Wrong written state: %t (flags: %04b)", written, flags)
+		}
+	}
+}
+
+func TestResponseWriterStatusSizeBefore(t *testing.T) {
+	h := &historyWriter{}
+	w := httptest.NewRecorder()
+	res := newResponseWriter(w)
+
+	res.Before(func(rw ResponseWriter) {
+		h.WriteString("before1")
+	})
+
+	res.Before(func(rw ResponseWriter) {
+		h.WriteString("before2")
+
+		if status := rw.Status(); status != http.StatusCreated {
+			t.Errorf("Wrong status in before hook: %d != %d", status, http.StatusCreated)
+		}
+
+		if _, ok := rw.(http.Flusher); !ok {
+			t.Error("Expected before hook to receive a http.Flusher")
+		}
+
+		rw.Header().Set("X-Before", "true")
+	})
+
+	if res.Written() || res.Status() != 0 || res.Size() != 0 {
+		t.Fatal("Expected an unwritten ResponseWriter")
+	}
+
+	res.WriteHeader(http.StatusCreated)
+	res.Write([]byte("hello"))
+	res.Write([]byte(" world"))
+
+	if status := res.Status(); status != http.StatusCreated {
+		t.Errorf("Wrong status: %d != %d", status, http.StatusCreated)
+	}
+
+	if size := res.Size(); size != 11 {
+		t.Errorf("Wrong size: %d != %d", size, 11)
+	}
+
+	if w.Header().Get("X-Before") != "true" {
+		t.Error("Missing header set in before hook")
+	}
+
+	// Hooks are invoked once in reverse order.
+	if h.Len() != 2 || h.At(0) != "before2" || h.At(1) != "before1" {
+		t.Errorf("Wrong before hook invocations: %v", h.writes)
+	}
+
+	// Further status codes are ignored like by the http package.
+	res.WriteHeader(http.StatusInternalServerError)
+
+	if status := res.Status(); status != http.StatusCreated {
+		t.Errorf("Wrong status after second WriteHeader: %d != %d", status, http.StatusCreated)
+	}
+}
+
+func TestResponseWriterInformational(t *testing.T) {
+	w := httptest.NewRecorder()
+	res := newResponseWriter(w)
+
+	res.WriteHeader(http.StatusEarlyHints)
+
+	if res.Written() {
+		t.Error("Informational status must not complete the header")
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+
+	if status := res.Status(); status != http.StatusNoContent {
+		t.Errorf("Wrong status: %d != %d", status, http.StatusNoContent)
+	}
+}
+
+// A deadlineWriter records write deadlines set by http.ResponseController.
+type deadlineWriter struct {
+	http.ResponseWriter
+	deadline time.Time
+}
+
+func (d *deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	d.deadline = deadline
+	return nil
+}
+
+func TestResponseWriterUnwrap(t *testing.T) {
+	w := &deadlineWriter{ResponseWriter: httptest.NewRecorder()}
+	res := newResponseWriter(w)
+
+	deadline := time.Now().Add(time.Minute)
+	if err := http.NewResponseController(res).SetWriteDeadline(deadline); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !w.deadline.Equal(deadline) {
+		t.Errorf("Wrong deadline: %v != %v", w.deadline, deadline)
+	}
+}
@@ -15,6 +15,25 @@ import (
 type Route struct {
 	methods map[string][]http.HandlerFunc
 	path    *path
+	pattern string
+	name    string
+	router  *Router // Mounted sub router or nil
+
+	// Additional conditions a request must fulfill.
+	matchers []requestMatcher
+
+	middleware bool
+	prefix     bool
+
+	strict    bool
+	treeRoute *treeRoute // Position in the routeTree or nil
+}
+
+// Name sets the name of the Route, which can be used to build URLs
+// with Router.URL.
+func (r *Route) Name(name string) *Route {
+	r.name = name
+	return r
 }
 
 // All registers the specified functions for all methods in the order of appearance.
@@ -84,17 +103,38 @@ func (r *Route) Rest(fn http.HandlerFunc) *Route {
 // The processing stops as soon as a handler writes a response or set's an error
 // on the RequestContext.
 func (r *Route) serveHTTP(res http.ResponseWriter, req *http.Request) {
+	r.serveMethod(req.Method, res, req)
+}
+
+// serveMethod is like serveHTTP, but processes the handlers registered for
+// method instead of the request's method.
+func (r *Route) serveMethod(method string, res http.ResponseWriter, req *http.Request) {
 	ctx := Context(req)
 
-	for _, handler := range r.methods[req.Method] {
+	for _, handler := range r.methods[method] {
 		handler(res, req)
 
-		if doneProcessing(res.(*responseWriter), ctx) {
+		if doneProcessing(res, ctx) {
 			return
 		}
 	}
 }
 
+func (r *Route) handles(method string) bool {
+	return len(r.methods[method]) > 0
+}
+
+func (r *Route) registeredMethods() []string {
+	methods := make([]string, 0, len(r.methods))
+	for method, handlers := range r.methods {
+		if len(handlers) > 0 {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
 func (r *Route) match(path string) bool {
 	return r.path.Match(path)
 }
@@ -107,18 +147,26 @@ func (r *Route) params() []string {
 	return r.path.Params()
 }
 
-func (r *Route) fillParams(path string, params map[string]string) {
-	if !r.path.ContainsParams() {
-		return
-	}
-
-	r.path.FillParams(path, params)
+func (r *Route) fillParams(path string, params map[string]string) []string {
+	return r.path.FillParams(path, params)
 }
 
 func (r *Route) addMethodHandlerFunc(method string, fn http.HandlerFunc) {
 	r.methods[method] = append(r.methods[method], fn)
 }
 
+// Returns a prefix Route with an empty pattern, which matches all
+// paths without consuming any part of them.
+func newScopeRoute(strict bool) *Route {
+	return &Route{
+		methods:   make(map[string][]http.HandlerFunc),
+		path:      &path{matcher: &allMatcher{}},
+		prefix:    true,
+		strict:    strict,
+		treeRoute: &treeRoute{all: true},
+	}
+}
+
 func newRoute(pattern string, strict, prefixOnly bool) *Route {
 	path, err := parsePath(pattern, strict, prefixOnly)
 
@@ -127,7 +175,11 @@ func newRoute(pattern string, strict, prefixOnly bool) *Route {
 	}
 
 	return &Route{
-		methods: make(map[string][]http.HandlerFunc),
-		path:    path,
+		methods:   make(map[string][]http.HandlerFunc),
+		path:      path,
+		pattern:   pattern,
+		prefix:    prefixOnly,
+		strict:    strict,
+		treeRoute: newTreeRoute(pattern, path, prefixOnly),
 	}
 }
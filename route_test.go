@@ -16,7 +16,7 @@ func TestRouteHandlerChain(t *testing.T) {
 	req, _ := http.NewRequest(http.MethodGet, "/", nil)
 	history := newHistoryHandler()
 
-	createRequestContext(req)
+	req = createRequestContext(req)
 	ctx := Context(req)
 
 	route := newRoute("/", false, false)
@@ -42,8 +42,8 @@ func TestRouteHandlerChain(t *testing.T) {
 		}
 	}
 
-	if res.Code() != http.StatusOK {
-		t.Errorf("Wrong status code: %d != %d", res.Code(), http.StatusOK)
+	if res.Status() != http.StatusOK {
+		t.Errorf("Wrong status code: %d != %d", res.Status(), http.StatusOK)
 	}
 
 	if w.Body.String() != "3" {
@@ -57,7 +57,7 @@ func TestRouteRest(t *testing.T) {
 	req, _ := http.NewRequest("", "/", nil)
 	history := newHistoryHandler()
 
-	createRequestContext(req)
+	req = createRequestContext(req)
 
 	route := newRoute("/", false, false)
 
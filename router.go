@@ -7,6 +7,7 @@ package goserv
 import (
 	"fmt"
 	"net/http"
+	"runtime/debug"
 	"strings"
 )
 
@@ -17,6 +18,9 @@ import (
 type Router struct {
 	// Handles errors set on the RequestContext with .Error, not found errors
 	// and recovered panics.
+	//
+	// The ErrorHandler is invoked after all error handlers registered with
+	// .UseError passed on the error.
 	ErrorHandler ErrorHandlerFunc
 
 	// Defines how Routes treat the trailing slash in a path.
@@ -25,12 +29,41 @@ type Router struct {
 	// than routes without a trailing slash.
 	StrictSlash bool
 
-	// Enables/Disables panic recovery
+	// Enables/Disables panic recovery.
+	//
+	// Recovered panics are converted to a *PanicError and passed to the ErrorHandler.
+	// Routers without an ErrorHandler pass the error on to their parent Router. If no
+	// Router handles the error, the Server responds with "500 Internal Server Error".
 	PanicRecovery bool
 
+	// Invoked with every panic recovered by this Router, e.g. to report panics
+	// to a logging system. If nil, the PanicHandler of the nearest parent
+	// Router is used.
+	PanicHandler func(*http.Request, *PanicError)
+
+	// Enables/Disables automatic HEAD handling.
+	//
+	// When enabled HEAD requests to routes without HEAD handlers are dispatched
+	// to the route's GET handlers and the response body is discarded.
+	AutoHead bool
+
+	// Enables/Disables automatic OPTIONS handling.
+	//
+	// When enabled OPTIONS requests to routes without OPTIONS handlers are answered
+	// with an empty response and the "Allow" header listing the registered methods.
+	AutoOptions bool
+
 	path          string
+	parent        *Router
+	scoped        bool // Shares the path of its parent, see Group
 	paramHandlers paramHandlerMap
+	errorHandlers []errorHandler
 	routes        []*Route
+
+	// Routes are either stored in the tree or matched one by one, in
+	// which case the fallback list contains their index in routes.
+	tree     routeTree
+	fallback []int
 }
 
 // All registers the specified HandlerFunc for the given path for
@@ -82,13 +115,25 @@ func (r *Router) Patch(path string, fn http.HandlerFunc) *Router {
 // Use registers the specified function as middleware.
 // Middleware is always processed before any dispatching happens.
 func (r *Router) Use(fn http.HandlerFunc) *Router {
-	r.Route("/*").All(fn)
+	r.middleware().All(fn)
 	return r
 }
 
 // UseHandler is an adapter for Use to register a Handler as middleware.
 func (r *Router) UseHandler(handler http.Handler) *Router {
-	r.Route("/*").All(handler.ServeHTTP)
+	r.middleware().All(handler.ServeHTTP)
+	return r
+}
+
+// UseError registers an error handler which is invoked for errors matching all of the
+// specified matchers. Without matchers the handler is invoked for all errors.
+//
+// Errors are passed to the matching error handlers in the order they were registered,
+// followed by the ErrorHandler. An error handler can decline an error by calling
+// .PassError on the RequestContext, in which case the error is passed to the next
+// error handler or, if there is none, to the error handlers of the parent Router.
+func (r *Router) UseError(fn ErrorHandlerFunc, matchers ...ErrorMatcher) *Router {
+	r.errorHandlers = append(r.errorHandlers, errorHandler{fn, matchers})
 	return r
 }
 
@@ -104,18 +149,51 @@ func (r *Router) Param(name string, fn ParamHandlerFunc) *Router {
 
 // SubRouter returns a new sub router mounted on the specified prefix.
 //
-// All sub routers automatically inherit their StrictSlash behaviour,
-// have the full mount path and no error handler. It is possible though
+// All sub routers automatically inherit their StrictSlash, AutoHead and AutoOptions
+// behaviour, have the full mount path and no error handler. It is possible though
 // to set a custom error handler for a sub router.
 //
 // Note that this function returns the new sub router instead of the
 // parent router!
 func (r *Router) SubRouter(prefix string) *Router {
+	router := r.newSubRouter(r.path + prefix)
+
+	route := newRoute(prefix, r.StrictSlash, true).All(router.serveHTTP)
+	route.router = router
+	r.addRoute(route)
+
+	return router
+}
+
+// Group invokes fn with a new sub router sharing the Router's path, which allows
+// registering middleware for a group of Routes without a common prefix:
+//
+//	api.Group(func(private *goserv.Router) {
+//		private.Use(authenticate)
+//		private.Get("/users", listUsers)
+//	})
+//	api.Get("/status", status) // Not authenticated
+//
+// Like any other sub router the group can have its own error handlers and is
+// left by calling .SkipRouter on the RequestContext, in which case the processing
+// continues after the group. Requests not handled by any of the group's Routes are
+// passed on to the Routes registered after the group, so "not found" errors are
+// never handled by the group. The group's middleware is only invoked for requests
+// whose path matches one of the group's Routes.
+func (r *Router) Group(fn func(*Router)) *Router {
+	fn(r.scope())
+	return r
+}
+
+// Returns a new Router with the specified path inheriting the
+// behaviour of the Router.
+func (r *Router) newSubRouter(path string) *Router {
 	router := newRouter()
 	router.StrictSlash = r.StrictSlash
-	router.path = r.path + prefix
-
-	r.addRoute(newRoute(prefix, r.StrictSlash, true).All(router.serveHTTP))
+	router.AutoHead = r.AutoHead
+	router.AutoOptions = r.AutoOptions
+	router.path = path
+	router.parent = r
 
 	return router
 }
@@ -132,6 +210,71 @@ func (r *Router) Path() string {
 	return r.path
 }
 
+// URL builds the URL path of the Route registered with the specified name.
+//
+// Routes are looked up in the whole router tree, i.e. starting at the top level
+// Router and including all sub routers, in the order they were registered.
+// The path includes the mount paths of all sub routers.
+//
+// Parameter values are passed as key-value pairs, e.g.
+//
+//	router.URL("user", "user_id", "123")
+//
+// An error is returned if no Route with that name exists, a parameter value is missing
+// or a value doesn't match the parameter's pattern.
+func (r *Router) URL(name string, pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("odd number of parameter key-value pairs")
+	}
+
+	values := make(params)
+	for i := 0; i < len(pairs); i += 2 {
+		values[pairs[i]] = pairs[i+1]
+	}
+
+	root := r
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	chain := root.findRoute(name)
+	if chain == nil {
+		return "", fmt.Errorf("no route named %q", name)
+	}
+
+	var url string
+	for _, route := range chain {
+		part, err := route.path.Build(values)
+		if err != nil {
+			return "", fmt.Errorf("route %q: %s", name, err)
+		}
+
+		url += part
+	}
+
+	return url, nil
+}
+
+// Returns the named Route preceded by the mount Routes of all sub routers
+// leading to it or nil if no Route has the specified name.
+func (r *Router) findRoute(name string) []*Route {
+	for _, route := range r.routes {
+		if route.name == name {
+			return []*Route{route}
+		}
+
+		if route.router == nil {
+			continue
+		}
+
+		if chain := route.router.findRoute(name); chain != nil {
+			return append([]*Route{route}, chain...)
+		}
+	}
+
+	return nil
+}
+
 func (r *Router) serveHTTP(res http.ResponseWriter, req *http.Request) {
 	if r.PanicRecovery {
 		defer r.handleRecovery(res, req)
@@ -141,15 +284,32 @@ func (r *Router) serveHTTP(res http.ResponseWriter, req *http.Request) {
 
 	r.invokeHandlers(res, req, ctx)
 
-	if res.(*responseWriter).Written() || r.ErrorHandler == nil {
+	if internalWriter(res).Written() || ctx.errHandled || !r.handlesErrors() {
+		return
+	}
+
+	// Scoped routers leave unmatched requests to their parent, which
+	// continues with the routes registered after the scope.
+	if ctx.err == nil && r.scoped {
 		return
 	}
 
 	if ctx.err == nil {
-		ctx.Error(ErrNotFound, http.StatusNotFound)
+		if allowed := ctx.allowedMethods(); len(allowed) > 0 {
+			res.Header().Set("Allow", strings.Join(allowed, ", "))
+
+			if req.Method == http.MethodOptions && r.AutoOptions {
+				res.WriteHeader(http.StatusOK)
+				return
+			}
+
+			ctx.Error(ErrMethodNotAllowed, http.StatusMethodNotAllowed)
+		} else {
+			ctx.Error(ErrNotFound, http.StatusNotFound)
+		}
 	}
 
-	r.ErrorHandler(res, req, ctx.err)
+	r.handleError(res, req, ctx)
 }
 
 func (r *Router) invokeHandlers(res http.ResponseWriter, req *http.Request, ctx *RequestContext) {
@@ -159,13 +319,53 @@ func (r *Router) invokeHandlers(res http.ResponseWriter, req *http.Request, ctx
 
 	paramInvoked := make(map[string]bool)
 
-	for _, route := range r.routes {
-		if !route.match(path) {
+	segments, leaves := r.tree.Lookup(path)
+	fallback := r.fallback
+
+	// Merge the matches from the tree with the fallback routes to
+	// process all routes in the order in which they were registered.
+	for len(leaves) > 0 || len(fallback) > 0 {
+		var route *Route
+		var leaf *treeLeaf
+
+		if len(leaves) > 0 && (len(fallback) == 0 || leaves[0].index < fallback[0]) {
+			leaf, leaves = leaves[0], leaves[1:]
+			route = r.routes[leaf.index]
+		} else {
+			route, fallback = r.routes[fallback[0]], fallback[1:]
+
+			if !route.match(path) {
+				continue
+			}
+		}
+
+		if !route.matchRequest(req, ctx.params) {
 			continue
 		}
 
+		// Remember the methods of routes matching only the path, they
+		// make up the "Allow" header of a "method not allowed" error.
+		method := r.dispatchMethod(route, req.Method)
+		if len(method) == 0 {
+			ctx.allowMethods(r.allowedMethods(route))
+			continue
+		}
+
+		if method != req.Method {
+			internalWriter(res).discardBody()
+		}
+
+		if leaf != nil {
+			leaf.fillParams(segments, ctx.params)
+		} else {
+			if captures := route.fillParams(path, ctx.params); captures != nil {
+				ctx.captures = captures
+			}
+		}
+
+		ctx.setConstraints(route)
+
 		// Call param handlers in the same order in which the parameters appear in the path.
-		route.fillParams(path, ctx.params)
 		for _, name := range route.params() {
 			if paramInvoked[name] {
 				continue
@@ -176,7 +376,7 @@ func (r *Router) invokeHandlers(res http.ResponseWriter, req *http.Request, ctx
 			for _, paramHandler := range r.paramHandlers[name] {
 				paramHandler(res, req, value)
 
-				if doneProcessing(res.(*responseWriter), ctx) {
+				if doneProcessing(res, ctx) {
 					return
 				}
 			}
@@ -184,27 +384,164 @@ func (r *Router) invokeHandlers(res http.ResponseWriter, req *http.Request, ctx
 			paramInvoked[name] = true
 		}
 
-		route.serveHTTP(res, req)
+		if !route.middleware && !route.prefix {
+			ctx.pattern = r.path + route.pattern
+		}
+
+		route.serveMethod(method, res, req)
 
-		if doneProcessing(res.(*responseWriter), ctx) {
+		if doneProcessing(res, ctx) {
 			return
 		}
 	}
 }
 
+// Returns true if the request path matches a Route of the Router other than
+// middleware, including the Routes of its sub routers.
+func (r *Router) matchesRoute(req *http.Request) bool {
+	path := strings.TrimPrefix(SanitizePath(req.URL.Path), r.path)
+
+	_, leaves := r.tree.Lookup(path)
+	for _, leaf := range leaves {
+		if r.acceptsRoute(r.routes[leaf.index], req) {
+			return true
+		}
+	}
+
+	for _, index := range r.fallback {
+		if route := r.routes[index]; route.match(path) && r.acceptsRoute(route, req) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns true if the path matching route processes the request, see matchesRoute.
+func (r *Router) acceptsRoute(route *Route, req *http.Request) bool {
+	if route.middleware || !route.matchRequest(req, make(params)) {
+		return false
+	}
+
+	return route.router == nil || route.router.matchesRoute(req)
+}
+
+// Returns the method whose handlers process the request on the given route
+// or an empty string if the route has no handlers for the request method.
+func (r *Router) dispatchMethod(route *Route, method string) string {
+	if route.handles(method) {
+		return method
+	}
+
+	if method == http.MethodHead && r.AutoHead && route.handles(http.MethodGet) {
+		return http.MethodGet
+	}
+
+	return ""
+}
+
+// Returns the methods the given route responds to, including the
+// automatically handled HEAD and OPTIONS methods.
+func (r *Router) allowedMethods(route *Route) []string {
+	methods := route.registeredMethods()
+
+	if r.AutoHead && route.handles(http.MethodGet) && !route.handles(http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+
+	if r.AutoOptions && !route.handles(http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+
+	return methods
+}
+
 func (r *Router) handleRecovery(res http.ResponseWriter, req *http.Request) {
-	if err := recover(); err != nil && r.ErrorHandler != nil {
-		r.ErrorHandler(res, req, &ContextError{fmt.Errorf("Panic: %v", err), http.StatusInternalServerError})
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	// Aborting a handler is intended, so let the http package handle it.
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	err := &PanicError{Value: v, Stack: debug.Stack()}
+
+	for router := r; router != nil; router = router.parent {
+		if router.PanicHandler != nil {
+			router.PanicHandler(req, err)
+			break
+		}
+	}
+
+	// The panic replaces any previous error. Without error handlers the
+	// parent Router stops processing and handles the error instead.
+	ctx := Context(req)
+	ctx.err = InternalServerError(err)
+
+	r.handleError(res, req, ctx)
+}
+
+func (r *Router) handlesErrors() bool {
+	return len(r.errorHandlers) > 0 || r.ErrorHandler != nil
+}
+
+// Passes the ContextError to the matching error handlers and the ErrorHandler
+// until one of them handles the error, i.e. doesn't call .PassError.
+func (r *Router) handleError(res http.ResponseWriter, req *http.Request, ctx *RequestContext) {
+	for _, handler := range r.errorHandlers {
+		if handler.match(ctx.err) && ctx.invokeErrorHandler(handler.fn, res, req) {
+			return
+		}
+	}
+
+	if r.ErrorHandler != nil {
+		ctx.invokeErrorHandler(r.ErrorHandler, res, req)
 	}
 }
 
+func (r *Router) middleware() *Route {
+	route := r.Route("/*")
+	route.middleware = true
+	return route
+}
+
 func (r *Router) addRoute(route *Route) *Router {
+	index := len(r.routes)
 	r.routes = append(r.routes, route)
+
+	if route.treeRoute != nil {
+		r.tree.Insert(route.treeRoute, index, route.strict)
+	} else {
+		r.fallback = append(r.fallback, index)
+	}
+
 	return r
 }
 
 func newRouter() *Router {
-	return &Router{paramHandlers: make(paramHandlerMap)}
+	return &Router{
+		AutoHead:      true,
+		AutoOptions:   true,
+		paramHandlers: make(paramHandlerMap),
+	}
 }
 
 type paramHandlerMap map[string][]ParamHandlerFunc
+
+type errorHandler struct {
+	fn       ErrorHandlerFunc
+	matchers []ErrorMatcher
+}
+
+func (e errorHandler) match(err *ContextError) bool {
+	for _, matcher := range e.matchers {
+		if !matcher(err) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,6 +5,7 @@
 package goserv
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -49,6 +50,19 @@ func TestRouter(t *testing.T) {
 	skipper.Get("/skip", h.WriteHandler("not-handled"))
 	skiptest.Use(h.WriteHandler("last"))
 
+	// Groups
+	groups := router.SubRouter("/groups")
+	groups.Group(func(g *Router) {
+		g.Use(h.Handler("group-middleware"))
+		g.Get("/private", h.WriteHandler("private-handler"))
+	})
+	groups.Group(func(g *Router) {
+		g.Use(h.SkipRouterHandler("group-skip"))
+		g.Get("/skipped", h.WriteHandler("not-handled"))
+	})
+	groups.Get("/public", h.WriteHandler("public-handler"))
+	groups.Get("/skipped", h.WriteHandler("skipped-handler"))
+
 	tests := []struct {
 		method string
 		path   string
@@ -57,11 +71,12 @@ func TestRouter(t *testing.T) {
 		err    error
 	}{
 		{http.MethodGet, "/", []string{"middleware", "all-handler", "get-handler"}, "get-handler", nil},
-		{http.MethodPost, "/", []string{"middleware", "all-handler"}, "", ErrNotFound},
+		{http.MethodPost, "/", []string{"middleware", "all-handler"}, "", ErrMethodNotAllowed},
 
 		{http.MethodGet, "/multi", []string{"middleware", "multi-handler"}, "multi-handler", nil},
 		{http.MethodDelete, "/multi", []string{"middleware", "multi-handler"}, "multi-handler", nil},
-		{http.MethodPost, "/multi", []string{"middleware"}, "", ErrNotFound},
+		{http.MethodPost, "/multi", []string{"middleware"}, "", ErrMethodNotAllowed},
+		{http.MethodGet, "/missing", []string{"middleware"}, "", ErrNotFound},
 
 		{http.MethodGet, "/route", []string{"middleware", "route-handler"}, "route-handler", nil},
 
@@ -72,6 +87,10 @@ func TestRouter(t *testing.T) {
 		{http.MethodGet, "/srouter1/srouter2/error", []string{"middleware"}, "", fmt.Errorf("srouter2-error")},
 
 		{http.MethodGet, "/skiptest/skipper/skip", []string{"middleware", "skip-handler", "last"}, "last", nil},
+
+		{http.MethodGet, "/groups/private", []string{"middleware", "group-middleware", "private-handler"}, "private-handler", nil},
+		{http.MethodGet, "/groups/public", []string{"middleware", "public-handler"}, "public-handler", nil},
+		{http.MethodGet, "/groups/skipped", []string{"middleware", "group-skip", "skipped-handler"}, "skipped-handler", nil},
 	}
 
 	for index, test := range tests {
@@ -86,7 +105,7 @@ func TestRouter(t *testing.T) {
 			err = e.Err
 		}
 
-		createRequestContext(r)
+		r = createRequestContext(r)
 		router.serveHTTP(newResponseWriter(w), r)
 
 		if test.err != nil {
@@ -118,3 +137,274 @@ func TestRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	router := newRouter()
+	router.Get("/resource", DummyHandlerFunc)
+	router.Route("/resource").Put(DummyHandlerFunc).Delete(DummyHandlerFunc)
+	router.SubRouter("/sub").Post("/resource", DummyHandlerFunc)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		allow  string
+	}{
+		{http.MethodPost, "/resource", http.StatusMethodNotAllowed, "DELETE, GET, HEAD, OPTIONS, PUT"},
+		{http.MethodGet, "/sub/resource", http.StatusMethodNotAllowed, "OPTIONS, POST"},
+		{http.MethodGet, "/missing", http.StatusNotFound, ""},
+	}
+
+	for index, test := range tests {
+		var err *ContextError
+
+		router.ErrorHandler = func(w http.ResponseWriter, r *http.Request, e *ContextError) {
+			err = e
+			w.WriteHeader(e.Code)
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(test.method, test.path, nil)
+
+		router.serveHTTP(newResponseWriter(w), createRequestContext(r))
+
+		if err == nil {
+			t.Errorf("Expected error in ServeHTTP, but there is none (no. %d)", index)
+			continue
+		}
+
+		if err.Code != test.code {
+			t.Errorf("Wrong error code: %d != %d (no. %d)", err.Code, test.code, index)
+		}
+
+		if allow := w.Header().Get("Allow"); allow != test.allow {
+			t.Errorf("Wrong Allow header: %q != %q (no. %d)", allow, test.allow, index)
+		}
+	}
+}
+
+func TestRouterGroupMiddleware(t *testing.T) {
+	server := NewServer()
+	api := server.SubRouter("/api")
+
+	api.Group(func(private *Router) {
+		private.Use(func(w http.ResponseWriter, r *http.Request) {
+			Context(r).Error(errors.New("unauthorized"), http.StatusUnauthorized)
+		})
+		private.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+			WriteString(w, "users")
+		})
+	})
+	api.Get("/status", func(w http.ResponseWriter, r *http.Request) {
+		WriteString(w, "ok")
+	})
+
+	tests := []struct {
+		Method, Path string
+		Status       int
+	}{
+		{http.MethodGet, "/api/users", http.StatusUnauthorized},
+		{http.MethodPost, "/api/users", http.StatusUnauthorized},
+		{http.MethodGet, "/api/status", http.StatusOK},
+		{http.MethodGet, "/api/missing", http.StatusNotFound},
+	}
+
+	for index, test := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(test.Method, test.Path, nil))
+
+		if w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+	}
+}
+
+func TestRouterAutoHeadOptions(t *testing.T) {
+	h := newHistoryHandler()
+
+	router := newRouter()
+	router.ErrorHandler = func(w http.ResponseWriter, r *http.Request, e *ContextError) {
+		w.WriteHeader(e.Code)
+	}
+
+	router.Get("/resource", h.WriteHandler("get-handler")).Post("/resource", h.WriteHandler("post-handler"))
+	router.Route("/custom").Get(h.WriteHandler("get-handler")).Method(http.MethodOptions, h.WriteHandler("options-handler"))
+
+	tests := []struct {
+		method   string
+		path     string
+		autoHead bool
+		autoOpts bool
+		code     int
+		allow    string
+		writes   []string
+		body     string
+	}{
+		{http.MethodHead, "/resource", true, true, http.StatusOK, "", []string{"get-handler"}, ""},
+		{http.MethodHead, "/resource", false, true, http.StatusMethodNotAllowed, "GET, OPTIONS, POST", nil, ""},
+		{http.MethodOptions, "/resource", true, true, http.StatusOK, "GET, HEAD, OPTIONS, POST", nil, ""},
+		{http.MethodOptions, "/resource", true, false, http.StatusMethodNotAllowed, "GET, HEAD, POST", nil, ""},
+		{http.MethodOptions, "/custom", true, true, http.StatusOK, "", []string{"options-handler"}, "options-handler"},
+		{http.MethodOptions, "/missing", true, true, http.StatusNotFound, "", nil, ""},
+	}
+
+	for index, test := range tests {
+		router.AutoHead = test.autoHead
+		router.AutoOptions = test.autoOpts
+		h.Clear()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(test.method, test.path, nil)
+
+		router.serveHTTP(newResponseWriter(w), createRequestContext(r))
+
+		if w.Code != test.code {
+			t.Errorf("Wrong status code: %d != %d (no. %d)", w.Code, test.code, index)
+		}
+
+		if allow := w.Header().Get("Allow"); allow != test.allow {
+			t.Errorf("Wrong Allow header: %q != %q (no. %d)", allow, test.allow, index)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("Wrong body: %q != %q (no. %d)", w.Body.String(), test.body, index)
+		}
+
+		if len(test.writes) != h.Len() {
+			t.Errorf("Wrong write count %d != %d, %v (no. %d)", h.Len(), len(test.writes), h.writes, index)
+		}
+	}
+}
+
+func TestRouterURL(t *testing.T) {
+	router := newRouter()
+	router.Route("/").Name("home")
+	router.Route("/users/:user_id(\\d+)").Name("user")
+
+	api := router.SubRouter("/api")
+	v1 := api.SubRouter("/v1")
+	v1.Route("/items/:item/(edit)?").Name("item")
+
+	tests := []struct {
+		router *Router
+		name   string
+		pairs  []string
+		url    string
+		err    bool
+	}{
+		{router, "home", nil, "/", false},
+		{router, "user", []string{"user_id", "123"}, "/users/123", false},
+		{router, "item", []string{"item", "abc"}, "/api/v1/items/abc", false},
+		{v1, "user", []string{"user_id", "123"}, "/users/123", false},
+		{v1, "item", []string{"item", "abc"}, "/api/v1/items/abc", false},
+
+		{router, "user", []string{"user_id", "abc"}, "", true},
+		{router, "user", []string{"user_id"}, "", true},
+		{router, "missing", nil, "", true},
+	}
+
+	for index, test := range tests {
+		url, err := test.router.URL(test.name, test.pairs...)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error, got URL %q (no. %d)", url, index)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v (no. %d)", err, index)
+			continue
+		}
+
+		if url != test.url {
+			t.Errorf("Wrong URL: %q != %q (no. %d)", url, test.url, index)
+		}
+	}
+}
+
+type testError struct{ msg string }
+
+func (t *testError) Error() string { return t.msg }
+
+func TestRouterErrorHandlerChain(t *testing.T) {
+	h := newHistoryHandler()
+
+	handler := func(id string, pass bool) ErrorHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, err *ContextError) {
+			h.WriteString(id)
+
+			if pass {
+				Context(r).PassError()
+			}
+		}
+	}
+
+	errorRoute := func(err error, code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			Context(r).Error(err, code)
+		}
+	}
+
+	sentinel := errors.New("sentinel")
+
+	router := newRouter()
+	router.ErrorHandler = handler("root", false)
+	router.UseError(handler("root-sentinel", false), MatchError(sentinel))
+
+	api := router.SubRouter("/api")
+	api.UseError(handler("api-4xx", false), MatchStatus(400, 499))
+	api.UseError(handler("api-type", false), MatchErrorType[*testError]())
+	api.UseError(handler("api-5xx-pass", true), MatchStatus(500, 599))
+	api.Get("/bad", errorRoute(errors.New("bad"), http.StatusBadRequest))
+	api.Get("/typed", errorRoute(fmt.Errorf("wrapped: %w", &testError{"typed"}), http.StatusInternalServerError))
+	api.Get("/internal", errorRoute(errors.New("internal"), http.StatusInternalServerError))
+	api.Get("/sentinel", errorRoute(fmt.Errorf("wrapped: %w", sentinel), http.StatusInternalServerError))
+
+	api.Group(func(g *Router) {
+		g.UseError(handler("group", false))
+		g.Get("/group", errorRoute(errors.New("group"), http.StatusBadRequest))
+	})
+	api.Get("/after-group", errorRoute(errors.New("after"), http.StatusBadRequest))
+
+	nested := api.SubRouter("/nested")
+	nested.UseError(handler("nested-pass", true))
+	nested.Get("/bad", errorRoute(errors.New("bad"), http.StatusBadRequest))
+
+	tests := []struct {
+		path   string
+		writes []string
+	}{
+		{"/api/bad", []string{"api-4xx"}},
+		{"/api/missing", []string{"api-4xx"}},
+		{"/api/typed", []string{"api-type"}},
+		{"/api/internal", []string{"api-5xx-pass", "root"}},
+		{"/api/sentinel", []string{"api-5xx-pass", "root-sentinel"}},
+		{"/api/nested/bad", []string{"nested-pass", "api-4xx"}},
+		{"/api/group", []string{"group"}},
+		{"/api/after-group", []string{"api-4xx"}},
+		{"/missing", []string{"root"}},
+	}
+
+	for index, test := range tests {
+		h.Clear()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, test.path, nil)
+
+		router.serveHTTP(newResponseWriter(w), createRequestContext(r))
+
+		if len(test.writes) != h.Len() {
+			t.Errorf("Wrong write count %d != %d, %v (no. %d)", h.Len(), len(test.writes), h.writes, index)
+			continue
+		}
+
+		for pos, value := range test.writes {
+			if h.At(pos) != value {
+				t.Errorf("Wrong write value at %d: %s != %s (no. %d)", pos, h.At(pos), value, index)
+			}
+		}
+	}
+}
@@ -5,7 +5,14 @@
 package goserv
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 // A TLS contains both the certificate and key file paths.
@@ -24,6 +31,9 @@ type TLS struct {
 // http.ListenAndServe as well as http.ListenAndServeTLS and the possibility
 // to recover from panics.
 //
+// A listening Server can be shut down gracefully using .Shutdown or
+// .ShutdownOnSignal, which also invokes all hooks registered with .OnShutdown.
+//
 type Server struct {
 	// Embedded Router
 	*Router
@@ -33,34 +43,228 @@ type Server struct {
 
 	// TLS information set by .ListenTLS or nil if .Listen was used
 	TLS *TLS
+
+	// Renders templates for Render and RenderStatus, see .Templates.
+	Renderer Renderer
+
+	// Timeouts of the underlying http.Server, see the http.Server
+	// documentation for details. Zero means no timeout.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	mutex         sync.Mutex
+	server        *http.Server
+	done          chan struct{}
+	closed        bool
+	closing       chan struct{} // Closed by Shutdown
+	startHooks    []func() error
+	shutdownHooks []func(context.Context) error
 }
 
 // Listen is a convenience method that uses http.ListenAndServe.
+//
+// After a graceful shutdown Listen waits for .Shutdown to complete and returns nil.
 func (s *Server) Listen(addr string) error {
-	return http.ListenAndServe(addr, s)
+	s.Addr = addr
+	return s.serve(func(srv *http.Server) error {
+		return srv.ListenAndServe()
+	})
 }
 
 // ListenTLS is a convenience method that uses http.ListenAndServeTLS.
 // The TLS informations used are stored in .TLS after calling this method.
+//
+// After a graceful shutdown ListenTLS waits for .Shutdown to complete and returns nil.
 func (s *Server) ListenTLS(addr, certFile, keyFile string) error {
+	s.Addr = addr
 	s.TLS = &TLS{certFile, keyFile}
-	return http.ListenAndServeTLS(addr, certFile, keyFile, s)
+	return s.serve(func(srv *http.Server) error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// Serve accepts incoming connections on the Listener l. It behaves
+// like .Listen, but uses an existing Listener.
+func (s *Server) Serve(l net.Listener) error {
+	s.Addr = l.Addr().String()
+	return s.serve(func(srv *http.Server) error {
+		return srv.Serve(l)
+	})
+}
+
+// OnStart registers a hook which is invoked before the Server starts listening.
+// Hooks are invoked in the order they were registered. If a hook returns an error
+// the Server doesn't start and the error is returned by the listening method.
+func (s *Server) OnStart(fn func() error) *Server {
+	s.startHooks = append(s.startHooks, fn)
+	return s
+}
+
+// OnShutdown registers a hook which is invoked by .Shutdown after all connections
+// have been closed. Hooks are invoked in the order they were registered, e.g. to
+// close database pools after the last request finished.
+func (s *Server) OnShutdown(fn func(context.Context) error) *Server {
+	s.shutdownHooks = append(s.shutdownHooks, fn)
+	return s
+}
+
+// Shutdown gracefully shuts down the Server utilizing http.Server.Shutdown, which
+// stops accepting new connections and waits for all active requests to finish.
+// Afterwards all hooks registered with .OnShutdown are invoked with ctx.
+//
+// All hooks are invoked even if the shutdown fails or a hook returns an error.
+// The first error occured is returned.
+//
+// Only the first call shuts down the Server, further calls return nil immediately.
+// A Server which has been shut down can't be started again, the listening methods
+// return nil without serving any requests.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+
+	srv, done := s.server, s.done
+	s.server, s.done, s.closed = nil, nil, true
+
+	if s.closing != nil {
+		close(s.closing)
+	}
+	s.mutex.Unlock()
+
+	var err error
+
+	if srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+
+	for _, hook := range s.shutdownHooks {
+		if hookErr := hook(ctx); hookErr != nil && err == nil {
+			err = hookErr
+		}
+	}
+
+	if done != nil {
+		close(done)
+	}
+
+	return err
+}
+
+// ShutdownOnSignal shuts down the Server as soon as one of the specified signals
+// is received. The shutdown is forced after the timeout expired. If no signals
+// are specified os.Interrupt and syscall.SIGTERM are used.
+//
+// ShutdownOnSignal returns immediately, the returned channel receives the result
+// of .Shutdown. If the Server is shut down otherwise, the signals are no longer
+// handled and the channel is closed without receiving a result.
+func (s *Server) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	s.mutex.Lock()
+	if s.closing == nil {
+		s.closing = make(chan struct{})
+
+		if s.closed {
+			close(s.closing)
+		}
+	}
+
+	closing := s.closing
+	s.mutex.Unlock()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, signals...)
+
+	result := make(chan error, 1)
+
+	go func() {
+		select {
+		case <-sigs:
+			signal.Stop(sigs)
+		case <-closing:
+			signal.Stop(sigs)
+			close(result)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		result <- s.Shutdown(ctx)
+	}()
+
+	return result
+}
+
+func (s *Server) serve(fn func(*http.Server) error) error {
+	for _, hook := range s.startHooks {
+		if err := hook(); err != nil {
+			return err
+		}
+	}
+
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+	}
+
+	done := make(chan struct{})
+
+	s.mutex.Lock()
+	if s.closed {
+		// Shut down while starting, fn returns http.ErrServerClosed immediately.
+		srv.Close()
+		close(done)
+	} else {
+		s.server, s.done = srv, done
+	}
+	s.mutex.Unlock()
+
+	err := fn(srv)
+
+	if err == http.ErrServerClosed {
+		<-done
+		return nil
+	}
+
+	return err
 }
 
 // ServeHTTP dispatches the request to the internal Router.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	iw := newResponseWriter(w)
+	r = createRequestContext(r)
+
+	ctx := Context(r)
+	ctx.renderer = s.Renderer
+	defer ctx.finish()
 
-	createRequestContext(r)
-	defer deleteRequestContext(r)
+	res := newResponseWriter(w)
+	s.serveHTTP(res, r)
 
-	s.serveHTTP(iw, r)
+	// Recovered panics not handled by any error handler must not
+	// result in an empty "200 OK" response.
+	if ctx.err != nil && !ctx.errHandled && !res.Written() {
+		if _, ok := ctx.err.Err.(*PanicError); ok {
+			http.Error(res, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
 }
 
 // NewServer returns a newly allocated and initialized Server instance.
 //
-// By default the Server has no template engine, the template root is "" and
-// panic recovery is disabled. The Router's ErrorHandler is set to the StdErrorHandler.
+// By default the Server has no Renderer, panic recovery is disabled and HEAD as well
+// as OPTIONS requests are handled automatically. The Router's ErrorHandler is set to
+// the StdErrorHandler.
 func NewServer() *Server {
 	s := &Server{
 		Router: newRouter(),
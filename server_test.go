@@ -5,10 +5,16 @@
 package goserv
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRecovery(t *testing.T) {
@@ -65,3 +71,317 @@ func TestServerContext(t *testing.T) {
 		}
 	})
 }
+
+func TestServerContextWithContext(t *testing.T) {
+	type key struct{}
+
+	server := NewServer()
+
+	server.Use(func(w http.ResponseWriter, r *http.Request) {
+		Context(r).Set("test_key", "test_value")
+	})
+
+	server.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		derived := r.WithContext(context.WithValue(r.Context(), key{}, true))
+
+		ctx := Context(derived)
+		if ctx == nil {
+			t.Fatal("Missing RequestContext on derived request")
+		}
+
+		if ctx != Context(r) {
+			t.Error("Expected derived request to share the RequestContext")
+		}
+
+		if v, ok := ctx.Get("test_key").(string); !ok || v != "test_value" {
+			t.Errorf("Wrong key value, wanted: %q, got: %q", "test_value", v)
+		}
+
+		WriteString(w, "ok")
+	})
+
+	// Wrap the server with a stdlib handler which derives a new request.
+	handler := http.TimeoutHandler(server, time.Second, "timeout")
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Wrong status code: %d != %d", w.Code, http.StatusOK)
+	}
+
+	if body := w.Body.String(); body != "ok" {
+		t.Errorf("Wrong body: %q != %q", body, "ok")
+	}
+
+	if Context(r) != nil {
+		t.Error("Expected original request to have no RequestContext")
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	server := NewServer()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	server.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		WriteString(w, "done")
+	})
+
+	h := newHistoryHandler()
+
+	server.OnStart(func() error {
+		h.WriteString("start")
+		return nil
+	})
+
+	server.OnShutdown(func(context.Context) error {
+		h.WriteString("shutdown1")
+		return nil
+	}).OnShutdown(func(context.Context) error {
+		h.WriteString("shutdown2")
+		return nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(l) }()
+
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + l.Addr().String() + "/")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		body <- string(b)
+	}()
+
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- server.Shutdown(context.Background()) }()
+
+	// Give Shutdown some time to close the listener before releasing
+	// the active request.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if b := <-body; b != "done" {
+		t.Errorf("Wrong body of active request: %q != %q", b, "done")
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Unexpected shutdown error: %v", err)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("Unexpected serve error: %v", err)
+	}
+
+	for index, value := range []string{"start", "shutdown1", "shutdown2"} {
+		if h.Len() <= index || h.At(index) != value {
+			t.Errorf("Wrong hook order, expected %s at %d: %v", value, index, h.writes)
+		}
+	}
+}
+
+func TestServerShutdownOnce(t *testing.T) {
+	server := NewServer()
+
+	var shutdowns int
+	server.OnShutdown(func(context.Context) error {
+		shutdowns++
+		return nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(l) }()
+
+	// Wait until the Server is serving requests.
+	res, err := http.Get("http://" + l.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	res.Body.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Errorf("Unexpected shutdown error: %v (no. %d)", err, i)
+		}
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("Unexpected serve error: %v", err)
+	}
+
+	if shutdowns != 1 {
+		t.Errorf("Wrong number of shutdown hook calls: %d != 1", shutdowns)
+	}
+}
+
+func TestServerShutdownWhileStarting(t *testing.T) {
+	server := NewServer()
+
+	var shutdowns int
+	server.OnShutdown(func(context.Context) error {
+		shutdowns++
+		return nil
+	})
+
+	// Shut down after the start hooks, but before the Server listens.
+	server.OnStart(func() error {
+		return server.Shutdown(context.Background())
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer l.Close()
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(l) }()
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Unexpected serve error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Server still serving after shutdown")
+	}
+
+	if shutdowns != 1 {
+		t.Errorf("Wrong number of shutdown hook calls: %d != 1", shutdowns)
+	}
+}
+
+func TestServerShutdownOnSignal(t *testing.T) {
+	server := NewServer()
+
+	result := server.ShutdownOnSignal(time.Second, os.Interrupt)
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Errorf("Unexpected shutdown error: %v", err)
+	}
+
+	select {
+	case err, ok := <-result:
+		if ok {
+			t.Errorf("Unexpected result: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Signal handler still active after shutdown")
+	}
+
+	// Registering after the shutdown must not block either.
+	if _, ok := <-server.ShutdownOnSignal(time.Second, os.Interrupt); ok {
+		t.Error("Expected closed channel")
+	}
+}
+
+func TestServerStartHookError(t *testing.T) {
+	server := NewServer()
+	hookErr := errors.New("start failed")
+
+	server.OnStart(func() error { return hookErr })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer l.Close()
+
+	if err := server.Serve(l); err != hookErr {
+		t.Errorf("Wrong serve error: %v != %v", err, hookErr)
+	}
+}
+
+func TestRecoveryPanicError(t *testing.T) {
+	server := NewServer()
+
+	var reported *PanicError
+	server.PanicHandler = func(r *http.Request, err *PanicError) {
+		reported = err
+	}
+
+	// The sub router recovers, but has no ErrorHandler, so the
+	// error must be passed to the server's ErrorHandler.
+	sub := server.SubRouter("/sub")
+	sub.PanicRecovery = true
+	sub.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic(42)
+	})
+
+	server.Get("/sub/panic", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected processing to stop after the panic")
+	})
+
+	var ctxErr *ContextError
+	server.ErrorHandler = func(w http.ResponseWriter, r *http.Request, e *ContextError) {
+		ctxErr = e
+		w.WriteHeader(e.Code)
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/sub/panic", nil)
+	w := httptest.NewRecorder()
+
+	server.ServeHTTP(w, r)
+
+	if ctxErr == nil {
+		t.Fatal("Error expected")
+	}
+
+	if ctxErr.Code != http.StatusInternalServerError {
+		t.Errorf("Wrong error code: %d != %d", ctxErr.Code, http.StatusInternalServerError)
+	}
+
+	panicErr, ok := ctxErr.Err.(*PanicError)
+	if !ok {
+		t.Fatalf("Expected *PanicError, got %T", ctxErr.Err)
+	}
+
+	if panicErr.Value != 42 {
+		t.Errorf("Wrong panic value: %v != %v", panicErr.Value, 42)
+	}
+
+	if !strings.Contains(string(panicErr.Stack), "TestRecoveryPanicError") {
+		t.Errorf("Stack doesn't contain the panicking function:\n%s", panicErr.Stack)
+	}
+
+	if reported != panicErr {
+		t.Error("Expected the PanicHandler of the server to be invoked")
+	}
+}
+
+func TestRecoveryWithoutErrorHandler(t *testing.T) {
+	server := NewServer()
+	server.ErrorHandler = nil
+	server.PanicRecovery = true
+
+	server.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		panic("I am panicked")
+	})
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Wrong status: %d != %d", w.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	gopath "path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// StaticOptions configure the file server registered by Server.Static.
+type StaticOptions struct {
+	// Name of the file served for directory requests. Defaults to "index.html".
+	Index string
+
+	// Enables/Disables listings of directories without index file.
+	Listing bool
+
+	// Serves the index file of the root directory for all requests to
+	// missing files, e.g. for single page applications with client side routing.
+	SPA bool
+
+	// Serves precompressed ".br" and ".gz" siblings of the requested file,
+	// if the client accepts the encoding.
+	Precompressed bool
+
+	// Cache-Control header values per file extension, e.g. ".js". The value of
+	// the key "*" is used for all other files. Without a matching value no
+	// Cache-Control header is set.
+	CacheControl map[string]string
+
+	// Allows serving files and directories whose name starts with a period.
+	// By default they are treated as missing.
+	Dotfiles bool
+}
+
+// Static serves the files of fsys on the specified prefix, e.g.
+//
+//	server.Static("/assets", os.DirFS("public"), goserv.StaticOptions{})
+//
+// Only GET and HEAD requests are served. Requests for missing files are passed on to
+// the Routes registered after the file server. Range requests and conditional requests
+// using the ETag or Last-Modified headers are supported.
+//
+// Request paths are cleaned with SanitizePath, so it is impossible to access files
+// outside of fsys.
+func (s *Server) Static(prefix string, fsys fs.FS, opts StaticOptions) *Server {
+	if len(opts.Index) == 0 {
+		opts.Index = "index.html"
+	}
+
+	s.Mount(prefix, &fileServer{fsys, opts})
+	return s
+}
+
+type fileServer struct {
+	fsys fs.FS
+	opts StaticOptions
+}
+
+func (f *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.serveOrPass(w, r) {
+		http.NotFound(w, r)
+	}
+}
+
+// serveOrPass implements passHandler. Requests to missing files and requests
+// with methods other than GET and HEAD are passed on.
+func (f *fileServer) serveOrPass(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	p := SanitizePath(r.URL.Path)
+	name := strings.Trim(p, "/")
+	if len(name) == 0 {
+		name = "."
+	}
+
+	if !fs.ValidPath(name) || (!f.opts.Dotfiles && containsDotfile(name)) {
+		return false
+	}
+
+	info, err := fs.Stat(f.fsys, name)
+
+	if isNotExist(err) {
+		return f.opts.SPA && f.serveFile(w, r, f.opts.Index)
+	}
+
+	if err != nil {
+		Context(r).Error(err, http.StatusInternalServerError)
+		return true
+	}
+
+	if !info.IsDir() {
+		return f.serveFile(w, r, name)
+	}
+
+	// Redirect to the trailing slash, so relative links work as expected.
+	if !strings.HasSuffix(p, "/") {
+		target := Context(r).ForwardedPrefix() + p + "/"
+		if len(r.URL.RawQuery) > 0 {
+			target += "?" + r.URL.RawQuery
+		}
+
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return true
+	}
+
+	index := gopath.Join(name, f.opts.Index)
+	if info, err := fs.Stat(f.fsys, index); err == nil && !info.IsDir() {
+		return f.serveFile(w, r, index)
+	}
+
+	if f.opts.Listing {
+		f.serveListing(w, r, name)
+		return true
+	}
+
+	return false
+}
+
+// Serves the file name or one of its precompressed siblings. Returns
+// false if the file doesn't exist.
+func (f *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	h := w.Header()
+
+	if f.opts.Precompressed {
+		h.Add("Vary", "Accept-Encoding")
+
+		for _, encoding := range [...]struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+			if !acceptsEncoding(r, encoding.name) {
+				continue
+			}
+
+			file, info, err := f.open(name + encoding.ext)
+			if err != nil {
+				continue
+			}
+			defer file.Close()
+
+			// The type must not be detected from the compressed content.
+			h.Set("Content-Type", f.contentType(name))
+			h.Set("Content-Encoding", encoding.name)
+			f.serveContent(w, r, name, info, file)
+			return true
+		}
+	}
+
+	file, info, err := f.open(name)
+	if isNotExist(err) {
+		return false
+	}
+
+	if err != nil {
+		Context(r).Error(err, http.StatusInternalServerError)
+		return true
+	}
+	defer file.Close()
+
+	f.serveContent(w, r, name, info, file)
+	return true
+}
+
+// Opens a regular file, directories are reported as missing.
+func (f *fileServer) open(name string) (fs.File, fs.FileInfo, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+
+	return file, info, nil
+}
+
+// Returns the Content-Type of the uncompressed file name determined by its extension
+// or, like http.ServeContent, by its content. Defaults to "application/octet-stream".
+func (f *fileServer) contentType(name string) string {
+	if ctype := mime.TypeByExtension(gopath.Ext(name)); len(ctype) > 0 {
+		return ctype
+	}
+
+	file, _, err := f.open(name)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer file.Close()
+
+	var buf [512]byte
+	n, _ := io.ReadFull(file, buf[:])
+
+	return http.DetectContentType(buf[:n])
+}
+
+// Writes the file content utilizing http.ServeContent, which handles
+// range and conditional requests.
+func (f *fileServer) serveContent(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo, file fs.File) {
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			Context(r).Error(err, http.StatusInternalServerError)
+			return
+		}
+
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := fileETag(info, content)
+	if err != nil {
+		Context(r).Error(err, http.StatusInternalServerError)
+		return
+	}
+
+	if value, ok := f.cacheControl(name); ok {
+		w.Header().Set("Cache-Control", value)
+	}
+
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// Writes a HTML listing of the directory name.
+func (f *fileServer) serveListing(w http.ResponseWriter, r *http.Request, name string) {
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		Context(r).Error(err, http.StatusInternalServerError)
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<body>\n<ul>\n")
+
+	for _, entry := range entries {
+		entryName := entry.Name()
+
+		if !f.opts.Dotfiles && strings.HasPrefix(entryName, ".") {
+			continue
+		}
+
+		if entry.IsDir() {
+			entryName += "/"
+		}
+
+		href := (&url.URL{Path: entryName}).EscapedPath()
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(href), html.EscapeString(entryName))
+	}
+
+	buf.WriteString("</ul>\n</body>\n</html>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// Returns the Cache-Control value for the file name and true if there is one.
+func (f *fileServer) cacheControl(name string) (string, bool) {
+	if value, ok := f.opts.CacheControl[gopath.Ext(name)]; ok {
+		return value, true
+	}
+
+	value, ok := f.opts.CacheControl["*"]
+	return value, ok
+}
+
+// Returns true if err reports a missing file. Like http.FileServer this includes
+// paths using a file as directory, e.g. "app.css/x", so no internal paths are exposed
+// in error responses.
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) || errors.Is(err, syscall.ENOTDIR)
+}
+
+// Returns true if any element of the slash separated name starts with a period.
+func containsDotfile(name string) bool {
+	for _, element := range strings.Split(name, "/") {
+		if element != "." && strings.HasPrefix(element, ".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns true if the request's Accept-Encoding header contains the
+// encoding with a quality value greater than zero.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, value := range splitHeaderList(r.Header.Get("Accept-Encoding")) {
+		name, params, _ := strings.Cut(value, ";")
+
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		_, q, found := strings.Cut(strings.ReplaceAll(params, " ", ""), "q=")
+		if !found {
+			return true
+		}
+
+		v, err := strconv.ParseFloat(q, 64)
+		return err == nil && v > 0
+	}
+
+	return false
+}
+
+// Returns a strong ETag based on the size and modification time of the file.
+// If the modification time is unknown, e.g. for embedded files, the content
+// is hashed instead.
+func fileETag(info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf("\"%x-%x\"", info.Size(), info.ModTime().UnixNano()), nil
+	}
+
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("\"%x-%x\"", info.Size(), hash.Sum64()), nil
+}
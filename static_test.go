@@ -0,0 +1,147 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestServerStatic(t *testing.T) {
+	modTime := time.Date(2016, 5, 12, 10, 0, 0, 0, time.UTC)
+
+	fsys := fstest.MapFS{
+		"index.html":         {Data: []byte("root index"), ModTime: modTime},
+		"app.js":             {Data: []byte("console.log(1)"), ModTime: modTime},
+		"app.js.gz":          {Data: []byte("gzipped"), ModTime: modTime},
+		"app.js.br":          {Data: []byte("brotli"), ModTime: modTime},
+		"notes":              {Data: []byte("plain text"), ModTime: modTime},
+		"notes.gz":           {Data: []byte("\x1f\x8b\x08\x00compressed"), ModTime: modTime},
+		"docs/index.html":    {Data: []byte("docs index")},
+		"files/a.txt":        {Data: []byte("0123456789"), ModTime: modTime},
+		"files/b c.txt":      {Data: []byte("b")},
+		"files/.secret":      {Data: []byte("secret")},
+		".env":               {Data: []byte("secret")},
+		"spa/index.html":     {Data: []byte("spa index")},
+		"spa/assets/app.css": {Data: []byte("body{}")},
+	}
+
+	server := NewServer()
+	server.Static("/static", fsys, StaticOptions{
+		Listing:       true,
+		Precompressed: true,
+		CacheControl:  map[string]string{".js": "max-age=31536000", "*": "no-cache"},
+	})
+	server.Static("/spa", fstest.MapFS{"index.html": fsys["spa/index.html"], "assets/app.css": fsys["spa/assets/app.css"]}, StaticOptions{SPA: true})
+	server.Get("/static/dynamic", func(w http.ResponseWriter, r *http.Request) {
+		WriteString(w, "dynamic")
+	})
+
+	tests := []struct {
+		Method, Path string
+		Header       http.Header
+		Status       int
+		Body         string
+		RespHeader   http.Header
+	}{
+		{Path: "/static/", Status: http.StatusOK, Body: "root index", RespHeader: http.Header{"Cache-Control": {"no-cache"}}},
+		{Path: "/static/index.html", Status: http.StatusOK, Body: "root index"},
+		{Path: "/static/app.js", Status: http.StatusOK, Body: "console.log(1)", RespHeader: http.Header{"Cache-Control": {"max-age=31536000"}, "Vary": {"Accept-Encoding"}}},
+		{Path: "/static/app.js", Header: http.Header{"Accept-Encoding": {"gzip, deflate"}}, Status: http.StatusOK, Body: "gzipped", RespHeader: http.Header{"Content-Encoding": {"gzip"}, "Content-Type": {"text/javascript; charset=utf-8"}}},
+		{Path: "/static/app.js", Header: http.Header{"Accept-Encoding": {"gzip, br"}}, Status: http.StatusOK, Body: "brotli", RespHeader: http.Header{"Content-Encoding": {"br"}}},
+		{Path: "/static/app.js", Header: http.Header{"Accept-Encoding": {"br;q=0"}}, Status: http.StatusOK, Body: "console.log(1)"},
+		{Path: "/static/notes", Header: http.Header{"Accept-Encoding": {"gzip"}}, Status: http.StatusOK, RespHeader: http.Header{"Content-Encoding": {"gzip"}, "Content-Type": {"text/plain; charset=utf-8"}}},
+		{Path: "/static/docs", Status: http.StatusMovedPermanently, RespHeader: http.Header{"Location": {"/static/docs/"}}},
+		{Path: "/static/docs/", Status: http.StatusOK, Body: "docs index"},
+		{Path: "/static/files/a.txt", Header: http.Header{"Range": {"bytes=2-4"}}, Status: http.StatusPartialContent, Body: "234"},
+		{Path: "/static/files/a.txt", Header: http.Header{"If-Modified-Since": {modTime.Format(http.TimeFormat)}}, Status: http.StatusNotModified},
+		{Path: "/static/files/a.txt", Header: http.Header{"If-None-Match": {fmt.Sprintf("\"a-%x\"", modTime.UnixNano())}}, Status: http.StatusNotModified},
+		{Path: "/static/files/", Status: http.StatusOK, Body: "<!DOCTYPE html>\n<html>\n<body>\n<ul>\n<li><a href=\"a.txt\">a.txt</a></li>\n<li><a href=\"b%20c.txt\">b c.txt</a></li>\n</ul>\n</body>\n</html>\n"},
+		{Method: http.MethodHead, Path: "/static/files/a.txt", Status: http.StatusOK, Body: ""},
+		{Path: "/static/.env", Status: http.StatusNotFound},
+		{Path: "/static/files/.secret", Status: http.StatusNotFound},
+		{Path: "/static/../static_test.go", Status: http.StatusNotFound},
+		{Path: "/static/missing.txt", Status: http.StatusNotFound},
+		{Path: "/static/app.js/x", Status: http.StatusNotFound},
+		{Path: "/static/dynamic", Status: http.StatusOK, Body: "dynamic"},
+		{Method: http.MethodPost, Path: "/static/app.js", Status: http.StatusNotFound},
+		{Path: "/spa/assets/app.css", Status: http.StatusOK, Body: "body{}"},
+		{Path: "/spa/users/123", Status: http.StatusOK, Body: "spa index"},
+	}
+
+	for index, test := range tests {
+		method := test.Method
+		if len(method) == 0 {
+			method = http.MethodGet
+		}
+
+		req := httptest.NewRequest(method, test.Path, nil)
+		for key, values := range test.Header {
+			req.Header[key] = values
+		}
+
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		if w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+
+		if len(test.Body) > 0 && w.Body.String() != test.Body {
+			t.Errorf("Wrong body: %q != %q (no. %d)", w.Body.String(), test.Body, index)
+		}
+
+		for key, values := range test.RespHeader {
+			if value := strings.Join(w.Header()[key], ", "); value != values[0] {
+				t.Errorf("Wrong %s header: %q != %q (no. %d)", key, value, values[0], index)
+			}
+		}
+	}
+}
+
+func TestServerStaticDirFS(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	server := NewServer()
+	server.Static("/assets", os.DirFS(dir), StaticOptions{})
+	server.Static("/spa", os.DirFS(dir), StaticOptions{SPA: true, Index: "app.css"})
+
+	tests := []struct {
+		Path   string
+		Status int
+		Body   string
+	}{
+		{"/assets/app.css", http.StatusOK, "body{}"},
+		{"/assets/app.css/x", http.StatusNotFound, ""},
+		{"/spa/app.css/x", http.StatusOK, "body{}"},
+	}
+
+	for index, test := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.Path, nil))
+
+		if w.Code != test.Status {
+			t.Errorf("Wrong status: %d != %d (no. %d)", w.Code, test.Status, index)
+		}
+
+		if len(test.Body) > 0 && w.Body.String() != test.Body {
+			t.Errorf("Wrong body: %q != %q (no. %d)", w.Body.String(), test.Body, index)
+		}
+
+		if strings.Contains(w.Body.String(), "not a directory") {
+			t.Errorf("Internal error exposed: %q (no. %d)", w.Body.String(), index)
+		}
+	}
+}
@@ -5,7 +5,7 @@
 package goserv
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 )
 
@@ -56,7 +56,7 @@ func (h historyHandler) ParamHandler() ParamHandlerFunc {
 
 func (h historyHandler) HandlerWithError(v string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		Context(r).Error(fmt.Errorf(v), 500)
+		Context(r).Error(errors.New(v), 500)
 	}
 }
 
@@ -0,0 +1,393 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// A routeTree is a segment trie containing the Routes of a Router. It finds all
+// Routes matching a request path by walking the path's segments instead of
+// matching each Route's regular expression.
+//
+// Static segments and parameters with the default pattern are matched without
+// regular expressions. Segments with custom parameter patterns or constraints,
+// e.g. "/users/:id<int>", are stored as pattern nodes, whose regular expression is
+// only evaluated for the corresponding segment of paths reaching the node. Prefix
+// Routes, e.g. of sub routers, are stored at the node of their last complete segment.
+//
+// Routes whose patterns can match more than a single segment, i.e. containing
+// wildcards or optional parts, or non-prefix patterns with a trailing slash are
+// not part of the tree. The Router matches them linearly against every request.
+type routeTree struct {
+	root routeNode
+	all  []*treeLeaf // Routes matching all paths
+}
+
+type routeNode struct {
+	static   map[string]*routeNode
+	param    *routeNode
+	patterns []*patternNode
+	leaves   []*treeLeaf
+	prefixes []*treeLeaf // Prefix Routes ending at this node
+}
+
+// A patternNode matches a single segment using a regular expression.
+type patternNode struct {
+	rx   *regexp.Regexp
+	node routeNode
+}
+
+// A treeLeaf references a Route stored in a routeTree.
+type treeLeaf struct {
+	index  int  // Position of the Route in the Router's route list
+	strict bool // Strict slash behavior of the Route
+	params []treeParam
+
+	// Prefix Routes either match all paths reaching their node (open) or require
+	// the next segment to start with the partial segment, e.g. "v1" for "/api/v1".
+	open    bool
+	partial string
+}
+
+// A treeParam is a segment of a Route containing parameters. Segments with
+// a pattern are matched again to extract the named groups.
+type treeParam struct {
+	pos  int
+	name string
+	rx   *regexp.Regexp
+}
+
+// fillParams stores the parameter values captured from segments.
+func (t *treeLeaf) fillParams(segments []string, params params) {
+	for _, param := range t.params {
+		segment := segments[param.pos]
+
+		if param.rx == nil {
+			params[param.name] = segment
+			continue
+		}
+
+		matches := param.rx.FindStringSubmatch(segment)
+		for index, name := range param.rx.SubexpNames() {
+			if len(name) > 0 {
+				params[name] = matches[index]
+			}
+		}
+	}
+}
+
+type treeSegmentKind int
+
+const (
+	staticSegment treeSegmentKind = iota
+	paramSegment
+	patternSegment
+)
+
+// A treeSegment is a single segment of a Route's pattern.
+type treeSegment struct {
+	kind  treeSegmentKind
+	value string // Static text or parameter name
+	rx    *regexp.Regexp
+}
+
+// A treeRoute describes how a Route is stored in a routeTree.
+type treeRoute struct {
+	segments []treeSegment
+	prefix   bool
+	all      bool
+}
+
+// Insert adds the Route at index to the tree.
+func (t *routeTree) Insert(route *treeRoute, index int, strict bool) {
+	leaf := &treeLeaf{index: index, strict: strict}
+
+	if route.all {
+		t.all = append(t.all, leaf)
+		return
+	}
+
+	segments := route.segments
+
+	// The last segment of prefix Routes only needs to be a prefix of the
+	// path's segment, unless it is a parameter.
+	if route.prefix {
+		last := segments[len(segments)-1]
+
+		if last.kind == staticSegment {
+			leaf.partial = last.value
+			segments = segments[:len(segments)-1]
+		} else {
+			leaf.open = true
+		}
+	}
+
+	node := &t.root
+
+	for pos, segment := range segments {
+		switch segment.kind {
+		case paramSegment:
+			if node.param == nil {
+				node.param = &routeNode{}
+			}
+
+			node = node.param
+			leaf.params = append(leaf.params, treeParam{pos: pos, name: segment.value})
+		case patternSegment:
+			node = node.pattern(segment.rx)
+			leaf.params = append(leaf.params, treeParam{pos: pos, rx: segment.rx})
+		default:
+			if node.static == nil {
+				node.static = make(map[string]*routeNode)
+			}
+
+			child, ok := node.static[segment.value]
+			if !ok {
+				child = &routeNode{}
+				node.static[segment.value] = child
+			}
+
+			node = child
+		}
+	}
+
+	if route.prefix {
+		node.prefixes = append(node.prefixes, leaf)
+	} else {
+		node.leaves = append(node.leaves, leaf)
+	}
+}
+
+// Returns the child node matching segments with rx, which is
+// shared by all Routes using the same expression.
+func (n *routeNode) pattern(rx *regexp.Regexp) *routeNode {
+	for _, child := range n.patterns {
+		if child.rx.String() == rx.String() {
+			return &child.node
+		}
+	}
+
+	child := &patternNode{rx: rx}
+	n.patterns = append(n.patterns, child)
+
+	return &child.node
+}
+
+// Lookup returns the segments of path and the leaves of all matching Routes
+// ordered by their index.
+func (t *routeTree) Lookup(path string) ([]string, []*treeLeaf) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, t.all
+	}
+
+	segments := strings.Split(path[1:], "/")
+	leaves := t.root.collect(segments, false, nil)
+
+	// Routes in non-strict mode also match paths with a trailing slash.
+	if n := len(segments); n > 1 && segments[n-1] == "" {
+		leaves = t.root.collect(segments[:n-1], true, leaves)
+	}
+
+	if len(t.all) > 0 {
+		leaves = append(leaves, t.all...)
+	}
+
+	sort.Sort(leavesByIndex(leaves))
+
+	return segments, leaves
+}
+
+// Collects the leaves matching segments. If trimmed is set, the trailing slash
+// was removed from the path and only non-strict, non-prefix leaves match.
+func (n *routeNode) collect(segments []string, trimmed bool, leaves []*treeLeaf) []*treeLeaf {
+	if !trimmed {
+		for _, leaf := range n.prefixes {
+			if leaf.open || (len(segments) > 0 && strings.HasPrefix(segments[0], leaf.partial)) {
+				leaves = append(leaves, leaf)
+			}
+		}
+	}
+
+	if len(segments) == 0 {
+		for _, leaf := range n.leaves {
+			if !trimmed || !leaf.strict {
+				leaves = append(leaves, leaf)
+			}
+		}
+
+		return leaves
+	}
+
+	if child := n.static[segments[0]]; child != nil {
+		leaves = child.collect(segments[1:], trimmed, leaves)
+	}
+
+	// Parameters capture at least one character.
+	if n.param != nil && len(segments[0]) > 0 {
+		leaves = n.param.collect(segments[1:], trimmed, leaves)
+	}
+
+	for _, child := range n.patterns {
+		if child.rx.MatchString(segments[0]) {
+			leaves = child.node.collect(segments[1:], trimmed, leaves)
+		}
+	}
+
+	return leaves
+}
+
+type leavesByIndex []*treeLeaf
+
+func (l leavesByIndex) Len() int           { return len(l) }
+func (l leavesByIndex) Less(i, j int) bool { return l[i].index < l[j].index }
+func (l leavesByIndex) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+// newTreeRoute returns how a Route with the parsed pattern is stored in a
+// routeTree or nil if the Route must be matched linearly.
+//
+// This is the case for non-prefix patterns with a trailing slash, patterns containing
+// wildcards or optional parts, and prefix patterns ending with a custom parameter
+// pattern, which may match only a part of a segment.
+func newTreeRoute(pattern string, p *path, prefix bool) *treeRoute {
+	if _, ok := p.matcher.(*allMatcher); ok {
+		return &treeRoute{all: true}
+	}
+
+	if !strings.HasPrefix(pattern, "/") {
+		return nil
+	}
+
+	if !prefix && pattern != "/" && strings.HasSuffix(pattern, "/") {
+		return nil
+	}
+
+	parts := splitPattern(pattern[1:])
+	segments := make([]treeSegment, len(parts))
+
+	for index, part := range parts {
+		segment, ok := parseTreeSegment(part)
+		if !ok {
+			return nil
+		}
+
+		segments[index] = segment
+	}
+
+	if prefix && segments[len(segments)-1].kind == patternSegment {
+		return nil
+	}
+
+	return &treeRoute{segments: segments, prefix: prefix}
+}
+
+// Splits pattern at all slashes outside of parameter patterns and constraints.
+func splitPattern(pattern string) []string {
+	var parts []string
+	var level, start int
+	var constraint bool
+
+	for index, r := range pattern {
+		switch {
+		case constraint:
+			constraint = r != '>'
+		case r == '<':
+			constraint = true
+		case r == '(':
+			level++
+		case r == ')':
+			level--
+		case r == '/' && level == 0:
+			parts = append(parts, pattern[start:index])
+			start = index + 1
+		}
+	}
+
+	return append(parts, pattern[start:])
+}
+
+// Parses a single segment of a pattern. Returns false if the segment
+// can't be matched on its own.
+func parseTreeSegment(segment string) (treeSegment, bool) {
+	if !strings.ContainsAny(segment, ":*?()<>") {
+		return treeSegment{kind: staticSegment, value: segment}, true
+	}
+
+	if name, ok := strings.CutPrefix(segment, ":"); ok && len(name) > 0 && strings.IndexFunc(name, func(r rune) bool { return !isAlphaNumDash(r) }) < 0 {
+		return treeSegment{kind: paramSegment, value: name}, true
+	}
+
+	p, err := parsePath("/"+segment, true, false)
+	if err != nil {
+		return treeSegment{}, false
+	}
+
+	switch m := p.matcher.(type) {
+	case *stringMatcher:
+		return treeSegment{kind: staticSegment, value: m.path[1:]}, true
+	case *regexpMatcher:
+	default:
+		return treeSegment{}, false
+	}
+
+	for _, part := range p.parts {
+		if part.kind == wildcardPart || part.optional {
+			return treeSegment{}, false
+		}
+	}
+
+	// Match the segment without the leading slash.
+	expr, ok := strings.CutPrefix(p.params.String(), "^/")
+	if !ok {
+		return treeSegment{}, false
+	}
+
+	rx, err := regexp.Compile("^" + expr)
+	if err != nil || matchesSlash(rx.String()) {
+		return treeSegment{}, false
+	}
+
+	return treeSegment{kind: patternSegment, rx: rx}, true
+}
+
+// Returns true if the regular expression may match a slash, in which
+// case it could span multiple segments.
+func matchesSlash(expr string) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return true
+	}
+
+	var walk func(*syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			return true
+		case syntax.OpLiteral:
+			return strings.ContainsRune(string(re.Rune), '/')
+		case syntax.OpCharClass:
+			for i := 0; i+1 < len(re.Rune); i += 2 {
+				if re.Rune[i] <= '/' && '/' <= re.Rune[i+1] {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		for _, sub := range re.Sub {
+			if walk(sub) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return walk(re)
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTreeRoute(t *testing.T) {
+	tests := []struct {
+		Pattern string
+		Prefix  bool
+		Kinds   []treeSegmentKind // nil if the pattern isn't stored in the tree
+		All     bool
+	}{
+		{Pattern: "/", Kinds: []treeSegmentKind{staticSegment}},
+		{Pattern: "/abc", Kinds: []treeSegmentKind{staticSegment}},
+		{Pattern: "/abc/:id/def", Kinds: []treeSegmentKind{staticSegment, paramSegment, staticSegment}},
+		{Pattern: "/:id1/:id2", Kinds: []treeSegmentKind{paramSegment, paramSegment}},
+		{Pattern: "/abc", Prefix: true, Kinds: []treeSegmentKind{staticSegment}},
+		{Pattern: "/abc/", Prefix: true, Kinds: []treeSegmentKind{staticSegment, staticSegment}},
+		{Pattern: "/abc/:id", Prefix: true, Kinds: []treeSegmentKind{staticSegment, paramSegment}},
+		{Pattern: "/:id(\\d+)", Kinds: []treeSegmentKind{patternSegment}},
+		{Pattern: "/users/:id<int>/posts", Kinds: []treeSegmentKind{staticSegment, patternSegment, staticSegment}},
+		{Pattern: "/:id1:id2", Kinds: []treeSegmentKind{patternSegment}},
+		{Pattern: "/abc:id", Kinds: []treeSegmentKind{patternSegment}},
+		{Pattern: "/:p([^/]+)/x", Kinds: []treeSegmentKind{patternSegment, staticSegment}},
+		{Pattern: "/(abc)", Kinds: []treeSegmentKind{staticSegment}},
+		{Pattern: "/*", All: true},
+		{Pattern: "/*", Prefix: true, All: true},
+
+		{Pattern: "/abc/"},
+		{Pattern: "/ab*"},
+		{Pattern: "/*path"},
+		{Pattern: "/ab?c"},
+		{Pattern: "/a(bc)?d"},
+		{Pattern: "/:p(.+)"},
+		{Pattern: "/:p(a/b)"},
+		{Pattern: "/:id(\\d+)", Prefix: true},
+	}
+
+	for _, test := range tests {
+		p, err := parsePath(test.Pattern, false, test.Prefix)
+		if err != nil {
+			t.Fatalf("Unexpected parser error: %s", err)
+		}
+
+		route := newTreeRoute(test.Pattern, p, test.Prefix)
+
+		if route == nil {
+			if test.Kinds != nil || test.All {
+				t.Errorf("Pattern %s not stored in the tree", test.Pattern)
+			}
+
+			continue
+		}
+
+		var kinds []treeSegmentKind
+		for _, segment := range route.segments {
+			kinds = append(kinds, segment.kind)
+		}
+
+		if route.all != test.All || !reflect.DeepEqual(kinds, test.Kinds) {
+			t.Errorf("Wrong segments for %s, expected: %v (all: %t), actual: %v (all: %t)", test.Pattern, test.Kinds, test.All, kinds, route.all)
+		}
+	}
+}
+
+func TestTreeMatchesPath(t *testing.T) {
+	patterns := []struct {
+		Pattern string
+		Prefix  bool
+	}{
+		{Pattern: "/"},
+		{Pattern: "/abc"},
+		{Pattern: "/abc/def"},
+		{Pattern: "/abc/:id"},
+		{Pattern: "/abc/:id/def"},
+		{Pattern: "/:id1/:id2"},
+		{Pattern: "/users/new"},
+		{Pattern: "/users/:user_id"},
+		{Pattern: "/users/:id<int>"},
+		{Pattern: "/users/:id<int>/posts/:post(\\d+)-:slug"},
+		{Pattern: "/users/:id(\\d*)"},
+		{Pattern: "/v:version(\\d+)/:id"},
+		{Pattern: "/*"},
+		{Pattern: "/", Prefix: true},
+		{Pattern: "/abc", Prefix: true},
+		{Pattern: "/abc/", Prefix: true},
+		{Pattern: "/users/:id", Prefix: true},
+		{Pattern: "/users/:id/po", Prefix: true},
+	}
+
+	paths := []string{
+		"/", "/abc", "/abc/", "/abc/def", "/abc/def/", "/abc/123", "/abc/123/def",
+		"/abc/123/def/", "/abc/123/ghi", "/abcd", "/users/new", "/users/42", "/users/42/",
+		"/users/-7", "/users/x1", "/users/", "/users/42/posts/7-hello", "/users/42/posts/x-hello",
+		"/users/42/posts/7-hello/", "/users/42/postsx", "/v2/abc", "/v2/", "/vx/abc",
+		"/users", "/def", "abc", "",
+	}
+
+	for _, strict := range []bool{false, true} {
+		var tree routeTree
+		var parsed []*path
+
+		for index, pattern := range patterns {
+			p, err := parsePath(pattern.Pattern, strict, pattern.Prefix)
+			if err != nil {
+				t.Fatalf("Unexpected parser error: %s", err)
+			}
+
+			route := newTreeRoute(pattern.Pattern, p, pattern.Prefix)
+			if route == nil {
+				t.Fatalf("Pattern %s not stored in the tree", pattern.Pattern)
+			}
+
+			tree.Insert(route, index, strict)
+			parsed = append(parsed, p)
+		}
+
+		for _, testPath := range paths {
+			segments, leaves := tree.Lookup(testPath)
+
+			var matches []int
+			for _, leaf := range leaves {
+				matches = append(matches, leaf.index)
+
+				// Compare captured parameters with those of the regexp.
+				expected, actual := make(params), make(params)
+				parsed[leaf.index].FillParams(testPath, expected)
+				leaf.fillParams(segments, actual)
+
+				if !reflect.DeepEqual(expected, actual) {
+					t.Errorf("Wrong params for %s == %s, expected: %v, actual: %v", patterns[leaf.index].Pattern, testPath, expected, actual)
+				}
+			}
+
+			var expected []int
+			for index, p := range parsed {
+				if p.Match(testPath) {
+					expected = append(expected, index)
+				}
+			}
+
+			if !reflect.DeepEqual(matches, expected) {
+				t.Errorf("Wrong matches for %s (strict: %t), expected: %v, actual: %v", testPath, strict, expected, matches)
+			}
+		}
+	}
+}
@@ -83,6 +83,8 @@ func WriteStringf(w http.ResponseWriter, format string, v ...interface{}) {
 
 // ReadJSONBody decodes the request's body utilizing encoding/json. The body
 // is closed after the decoding and any errors occured are returned.
+//
+// See Bind for decoding bodies depending on their Content-Type.
 func ReadJSONBody(r *http.Request, result interface{}) error {
 	err := json.NewDecoder(r.Body).Decode(result)
 	r.Body.Close()
@@ -95,6 +97,6 @@ func ReadJSONBody(r *http.Request, result interface{}) error {
 }
 
 // Returns true if either a response was written or a ContextError occured.
-func doneProcessing(w *responseWriter, ctx *RequestContext) bool {
-	return w.Written() || ctx.err != nil || ctx.skip
+func doneProcessing(w http.ResponseWriter, ctx *RequestContext) bool {
+	return internalWriter(w).Written() || ctx.err != nil || ctx.skip
 }
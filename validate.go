@@ -0,0 +1,447 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A ValidationRule validates a value using the rule's parameter, e.g. "64" for
+// "max=64". The returned error describes the violation, e.g. "must be even", and
+// becomes the message of the FieldError.
+type ValidationRule func(value reflect.Value, param string) error
+
+// A FieldError describes a violated validation rule.
+type FieldError struct {
+	// Path of the field, e.g. "address.city" or "items[2].name". Fields are named
+	// by their JSON name, if they have one, otherwise by their Go name.
+	Field string `json:"field"`
+
+	// Name and parameter of the violated rule.
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+
+	// Description of the violation, e.g. "is required".
+	Message string `json:"message"`
+}
+
+// Error returns the field followed by the message.
+func (f *FieldError) Error() string {
+	return f.Field + " " + f.Message
+}
+
+// A ValidationError is returned by Validate and contains all
+// violations found.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error returns the messages of all FieldErrors.
+func (v *ValidationError) Error() string {
+	messages := make([]string, len(v.Errors))
+	for i, err := range v.Errors {
+		messages[i] = err.Error()
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// ContextError returns a ContextError with the status code "422 Unprocessable Entity".
+func (v *ValidationError) ContextError() *ContextError {
+	return UnprocessableEntity(v)
+}
+
+// ProblemMembers implements Problem and adds the FieldErrors as "errors"
+// to the problem details.
+func (v *ValidationError) ProblemMembers() map[string]interface{} {
+	return map[string]interface{}{"errors": v.Errors}
+}
+
+var ruleMutex sync.RWMutex
+var rules = map[string]ValidationRule{
+	"min":   validateMin,
+	"max":   validateMax,
+	"email": validateEmail,
+	"oneof": validateOneOf,
+}
+
+// Checks of the parameters and supported types of the built-in rules.
+var ruleChecks = map[string]func(t reflect.Type, param string) error{
+	"min":   checkLimit,
+	"max":   checkLimit,
+	"email": checkEmail,
+}
+
+// Compiled rules of all validated struct types, reset by RegisterRule.
+var structRules = make(map[reflect.Type]*compiledStruct)
+
+// RegisterRule registers a named ValidationRule, which can then be used in
+// "validate" struct tags. An existing rule with the same name, including the
+// built-in ones, is replaced.
+//
+// The built-in rules are "required", "omitempty", "min", "max", "email" and "oneof".
+func RegisterRule(name string, rule ValidationRule) {
+	ruleMutex.Lock()
+	rules[name] = rule
+	delete(ruleChecks, name)
+	structRules = make(map[reflect.Type]*compiledStruct)
+	ruleMutex.Unlock()
+}
+
+// Validate validates the struct v, or the struct v points to, using the rules in
+// the "validate" tags of its fields. Rules are separated by commas and parameters
+// follow an equals sign:
+//
+//	type User struct {
+//		Name  string   `json:"name" validate:"required,max=64"`
+//		Email string   `json:"email" validate:"omitempty,email"`
+//		Role  string   `json:"role" validate:"oneof=admin user"`
+//		Tags  []string `json:"tags" validate:"max=10"`
+//	}
+//
+// The rules have the following meaning:
+//
+//	required   The value must not be the zero value, pointers must not be nil.
+//	omitempty  Skips the remaining rules if the value is the zero value.
+//	min, max   Limit numbers, the length of strings and the number of elements.
+//	email      The string must be a valid email address.
+//	oneof      The value must be one of the space separated parameters.
+//
+// Nested structs, pointers to structs and the struct elements of slices, arrays and
+// maps are validated recursively. All violations are returned in a *ValidationError.
+//
+// The tags of each struct type are parsed once. Unknown rules, invalid parameters and
+// built-in rules applied to unsupported types are reported by a plain error instead
+// of a *ValidationError, since they are programming errors rather than invalid input.
+func Validate(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validation requires a struct, got %T", v)
+	}
+
+	var errs []*FieldError
+	if err := validateStruct(value, "", &errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{errs}
+	}
+
+	return nil
+}
+
+// A compiledStruct contains the parsed "validate" tags of a struct type.
+type compiledStruct struct {
+	fields []compiledField
+	err    error
+}
+
+// A compiledField is an exported field of a struct type.
+type compiledField struct {
+	index     int
+	name      string
+	anonymous bool
+	tagged    bool
+	rules     []compiledRule
+}
+
+// A compiledRule is a rule of a "validate" tag. The function of
+// "required" and "omitempty" is nil.
+type compiledRule struct {
+	name, param string
+	fn          ValidationRule
+}
+
+// Returns the compiled rules of the struct type t, which are cached.
+func compileStruct(t reflect.Type) (*compiledStruct, error) {
+	ruleMutex.RLock()
+	compiled, ok := structRules[t]
+	ruleMutex.RUnlock()
+
+	if ok {
+		return compiled, compiled.err
+	}
+
+	ruleMutex.Lock()
+	defer ruleMutex.Unlock()
+
+	compiled = &compiledStruct{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		cf := compiledField{index: i, name: fieldName(field), anonymous: field.Anonymous}
+
+		if tag, ok := field.Tag.Lookup("validate"); ok {
+			cf.tagged = true
+
+			ruleSet, err := compileTag(field.Type, tag)
+			if err != nil {
+				compiled.err = fmt.Errorf("invalid validate tag of field %s.%s: %s", t, field.Name, err)
+				break
+			}
+
+			cf.rules = ruleSet
+		}
+
+		compiled.fields = append(compiled.fields, cf)
+	}
+
+	structRules[t] = compiled
+	return compiled, compiled.err
+}
+
+// Parses the rules of a tag for a field of type t. Must be
+// called with ruleMutex locked.
+func compileTag(t reflect.Type, tag string) ([]compiledRule, error) {
+	var compiled []compiledRule
+
+	// Rules apply to the value pointers point to.
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch name {
+		case "":
+			continue
+		case "required", "omitempty":
+			compiled = append(compiled, compiledRule{name: name})
+			continue
+		}
+
+		fn, ok := rules[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+
+		if check, ok := ruleChecks[name]; ok {
+			if err := check(t, param); err != nil {
+				return nil, fmt.Errorf("rule %q: %s", name, err)
+			}
+		}
+
+		compiled = append(compiled, compiledRule{name, param, fn})
+	}
+
+	return compiled, nil
+}
+
+// Validates all fields of the struct v and appends violations to errs.
+func validateStruct(v reflect.Value, prefix string, errs *[]*FieldError) error {
+	compiled, err := compileStruct(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, field := range compiled.fields {
+		path := prefix + field.name
+		if field.anonymous {
+			path = strings.TrimSuffix(prefix, ".")
+		}
+
+		fv := v.Field(field.index)
+
+		if field.tagged && !validateValue(fv, field.rules, path, errs) {
+			continue
+		}
+
+		if err := validateNested(fv, path, field.anonymous, errs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validates the value using the compiled rules. Returns false if the
+// validation of nested values should be skipped.
+func validateValue(v reflect.Value, rules []compiledRule, path string, errs *[]*FieldError) bool {
+	for _, rule := range rules {
+		switch rule.name {
+		case "required":
+			if v.IsZero() {
+				*errs = append(*errs, &FieldError{Field: path, Rule: rule.name, Message: "is required"})
+				return false
+			}
+
+			continue
+		case "omitempty":
+			if v.IsZero() {
+				return false
+			}
+
+			continue
+		}
+
+		// Rules apply to the value pointers point to.
+		value := v
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return false
+			}
+
+			value = value.Elem()
+		}
+
+		if err := rule.fn(value, rule.param); err != nil {
+			*errs = append(*errs, &FieldError{Field: path, Rule: rule.name, Param: rule.param, Message: err.Error()})
+		}
+	}
+
+	return true
+}
+
+// Validates structs contained in v.
+func validateNested(v reflect.Value, path string, embedded bool, errs *[]*FieldError) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return nil
+		}
+
+		if embedded && len(path) == 0 {
+			return validateStruct(v, "", errs)
+		}
+
+		return validateStruct(v, path+".", errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), false, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateNested(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), false, errs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the JSON name of the field or its Go name.
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); len(name) > 0 && name != "-" {
+		return name
+	}
+
+	return field.Name
+}
+
+func validateMin(v reflect.Value, param string) error {
+	return validateLimit(v, param, func(value, limit float64) bool { return value >= limit },
+		"must be at least %s", "must be at least %s characters long", "must contain at least %s elements")
+}
+
+func validateMax(v reflect.Value, param string) error {
+	return validateLimit(v, param, func(value, limit float64) bool { return value <= limit },
+		"must be at most %s", "must be at most %s characters long", "must contain at most %s elements")
+}
+
+// Compares numbers or the length of strings, slices, arrays and maps to the
+// limit and returns an error with the message matching the kind of v. The
+// parameter and type were verified by checkLimit.
+func validateLimit(v reflect.Value, param string, ok func(value, limit float64) bool, numberMsg, stringMsg, lengthMsg string) error {
+	limit, _ := strconv.ParseFloat(param, 64)
+
+	var value float64
+	var msg string
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, msg = float64(v.Int()), numberMsg
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, msg = float64(v.Uint()), numberMsg
+	case reflect.Float32, reflect.Float64:
+		value, msg = v.Float(), numberMsg
+	case reflect.String:
+		value, msg = float64(len([]rune(v.String()))), stringMsg
+	case reflect.Slice, reflect.Array, reflect.Map:
+		value, msg = float64(v.Len()), lengthMsg
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	if !ok(value, limit) {
+		return fmt.Errorf(msg, param)
+	}
+
+	return nil
+}
+
+// Verifies the parameter of "min" and "max" and the type of the field.
+func checkLimit(t reflect.Type, param string) error {
+	if _, err := strconv.ParseFloat(param, 64); err != nil {
+		return fmt.Errorf("invalid parameter %q", param)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported type %s", t)
+}
+
+func validateEmail(v reflect.Value, _ string) error {
+	addr, err := mail.ParseAddress(v.String())
+	if err != nil || addr.Address != v.String() {
+		return errors.New("must be a valid email address")
+	}
+
+	return nil
+}
+
+// Verifies that "email" is used for strings.
+func checkEmail(t reflect.Type, _ string) error {
+	if t.Kind() != reflect.String {
+		return fmt.Errorf("unsupported type %s", t)
+	}
+
+	return nil
+}
+
+func validateOneOf(v reflect.Value, param string) error {
+	options := strings.Fields(param)
+	value := fmt.Sprint(v.Interface())
+
+	for _, option := range options {
+		if value == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+}
@@ -0,0 +1,214 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"omitempty,min=5,max=5"`
+}
+
+type ValidateBase struct {
+	Kind string `json:"kind" validate:"oneof=a b"`
+}
+
+type validateUser struct {
+	ValidateBase
+	Name      string            `json:"name" validate:"required,max=8"`
+	Email     string            `json:"email" validate:"omitempty,email"`
+	Age       int               `json:"age" validate:"min=18,max=99"`
+	Score     *float64          `json:"score" validate:"max=1"`
+	Tags      []string          `json:"tags" validate:"min=1"`
+	Address   validateAddress   `json:"address"`
+	Others    []validateAddress `json:"others"`
+	Secondary *validateAddress  `validate:"required"`
+	Even      int               `json:"even" validate:"even"`
+	internal  string            `validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	RegisterRule("even", func(v reflect.Value, _ string) error {
+		if v.Int()%2 != 0 {
+			return errors.New("must be even")
+		}
+
+		return nil
+	})
+
+	score := 1.5
+
+	tests := []struct {
+		Value  validateUser
+		Errors []string
+	}{
+		{
+			Value: validateUser{ValidateBase{"a"}, "joe", "joe@example.com", 30, nil, []string{"x"}, validateAddress{"Berlin", "10115"}, nil, &validateAddress{City: "Paris"}, 2, ""},
+		},
+		{
+			Value: validateUser{ValidateBase{"c"}, "", "joe", 10, &score, nil, validateAddress{"", "123"}, []validateAddress{{City: "Rome"}, {}}, nil, 3, ""},
+			Errors: []string{
+				"kind must be one of a, b",
+				"name is required",
+				"email must be a valid email address",
+				"age must be at least 18",
+				"score must be at most 1",
+				"tags must contain at least 1 elements",
+				"address.city is required",
+				"address.zip must be at least 5 characters long",
+				"others[1].city is required",
+				"Secondary is required",
+				"even must be even",
+			},
+		},
+		{
+			Value:  validateUser{ValidateBase{"b"}, "very long name", "Joe <joe@example.com>", 100, nil, []string{"x"}, validateAddress{City: "Oslo"}, nil, &validateAddress{}, 0, ""},
+			Errors: []string{"name must be at most 8 characters long", "email must be a valid email address", "age must be at most 99", "Secondary.city is required"},
+		},
+	}
+
+	for index, test := range tests {
+		err := Validate(&test.Value)
+
+		if len(test.Errors) == 0 {
+			if err != nil {
+				t.Errorf("Unexpected error: %v (no. %d)", err, index)
+			}
+
+			continue
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected ValidationError, got %v (no. %d)", err, index)
+			continue
+		}
+
+		var messages []string
+		for _, fieldErr := range validationErr.Errors {
+			messages = append(messages, fieldErr.Error())
+		}
+
+		if !reflect.DeepEqual(messages, test.Errors) {
+			t.Errorf("Wrong errors: %q != %q (no. %d)", messages, test.Errors, index)
+		}
+	}
+
+	if err := Validate("string"); err == nil {
+		t.Error("Expected error for non-struct value")
+	}
+}
+
+func TestBindValidation(t *testing.T) {
+	type createUser struct {
+		Name string `json:"name" validate:"required"`
+		Role string `json:"role" validate:"oneof=admin user"`
+	}
+
+	server := NewServer()
+	server.ErrorHandler = ProblemErrorHandler
+	server.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		var user createUser
+
+		opts := DefaultBindOptions
+		opts.Validate = true
+
+		var validationErr *ValidationError
+		if err := BindWith(r, &user, opts); errors.As(err, &validationErr) {
+			Context(r).SetError(validationErr.ContextError())
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"role":"guest"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Wrong status: %d != %d", w.Code, http.StatusUnprocessableEntity)
+	}
+
+	var problem struct {
+		Errors []FieldError `json:"errors"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []FieldError{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "role", Rule: "oneof", Param: "admin user", Message: "must be one of admin, user"},
+	}
+
+	if !reflect.DeepEqual(problem.Errors, expected) {
+		t.Errorf("Wrong field errors: %+v != %+v", problem.Errors, expected)
+	}
+}
+
+type validateInvalid struct {
+	Age int `validate:"unknown"`
+}
+
+func TestValidateInvalidTags(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			Age int `validate:"gte=0"`
+		}{},
+		&struct {
+			Name string `validate:"min=abc"`
+		}{},
+		&struct {
+			Admin bool `validate:"max=1"`
+		}{},
+		&struct {
+			Email *int `validate:"omitempty,email"`
+		}{},
+		&struct {
+			Nested []validateInvalid
+		}{Nested: []validateInvalid{{}}},
+	}
+
+	for index, test := range tests {
+		// Check twice to cover cached rules.
+		for i := 0; i < 2; i++ {
+			err := Validate(test)
+
+			var validationErr *ValidationError
+			if err == nil || errors.As(err, &validationErr) {
+				t.Errorf("Expected plain error, got %v (no. %d)", err, index)
+			}
+		}
+	}
+
+	type createUser struct {
+		Age int `json:"age" validate:"gte=0"`
+	}
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"age":3}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	var user createUser
+	if err := Bind(newRequest(), &user); err != nil || user.Age != 3 {
+		t.Errorf("Bind must not validate: %v, %+v", err, user)
+	}
+
+	if err := BindWith(newRequest(), &user, BindOptions{Validate: true}); err == nil {
+		t.Error("Expected error for unknown rule")
+	}
+}
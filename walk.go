@@ -0,0 +1,141 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ErrSkipRouter can be returned by a WalkFunc to skip the routes of
+// the sub router mounted by the visited Route.
+var ErrSkipRouter = errors.New("skip router")
+
+// A RouteInfo describes a registered Route.
+type RouteInfo struct {
+	// Full path including the mount paths of all sub routers.
+	Path string
+
+	// Pattern the Route was registered with.
+	Pattern string
+
+	// Name set with Route.Name or "".
+	Name string
+
+	// Names of all parameters in the order of appearance.
+	Params []string
+
+	// Sorted methods with at least one handler.
+	Methods []string
+
+	// True if the Route was registered with Router.Use or Router.UseHandler.
+	Middleware bool
+
+	// True if the Route matches all paths starting with its Pattern,
+	// e.g. the Route mounting a sub router.
+	Prefix bool
+
+	// The sub router mounted by this Route or nil.
+	Router *Router
+}
+
+// A WalkFunc is invoked by Router.Walk for each registered Route.
+//
+// If the returned error is ErrSkipRouter the routes of the sub router mounted
+// by the Route are skipped. Any other error stops the walk.
+type WalkFunc func(RouteInfo) error
+
+// Walk invokes fn for all Routes of the Router in the order they were registered.
+// Routes mounting a sub router are visited before the routes of the sub router.
+//
+// The error returned by fn is returned, except for ErrSkipRouter.
+func (r *Router) Walk(fn WalkFunc) error {
+	return r.walk(r.path, fn)
+}
+
+func (r *Router) walk(prefix string, fn WalkFunc) error {
+	for _, route := range r.routes {
+		methods := route.registeredMethods()
+		sort.Strings(methods)
+
+		info := RouteInfo{
+			Path:       prefix + route.pattern,
+			Pattern:    route.pattern,
+			Name:       route.name,
+			Params:     route.params(),
+			Methods:    methods,
+			Middleware: route.middleware,
+			Prefix:     route.prefix,
+			Router:     route.router,
+		}
+
+		err := fn(info)
+
+		if err == ErrSkipRouter {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if route.router == nil {
+			continue
+		}
+
+		if err := route.router.walk(info.Path, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteRouteTable writes a table of all Routes, sorted by path, to w.
+//
+// Each line contains the methods, the full path, the kind of the Route
+// ("route", "middleware" or "prefix") and its name.
+func (r *Router) WriteRouteTable(w io.Writer) error {
+	var routes []RouteInfo
+
+	r.Walk(func(info RouteInfo) error {
+		routes = append(routes, info)
+		return nil
+	})
+
+	sort.Stable(routesByPath(routes))
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHODS\tPATH\tKIND\tNAME")
+
+	for _, info := range routes {
+		methods := strings.Join(info.Methods, ",")
+		if len(info.Methods) == len(methodNames) {
+			methods = "*"
+		}
+
+		kind := "route"
+		switch {
+		case info.Middleware:
+			kind = "middleware"
+		case info.Prefix:
+			kind = "prefix"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", methods, info.Path, kind, info.Name)
+	}
+
+	return tw.Flush()
+}
+
+type routesByPath []RouteInfo
+
+func (r routesByPath) Len() int           { return len(r) }
+func (r routesByPath) Less(i, j int) bool { return r[i].Path < r[j].Path }
+func (r routesByPath) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
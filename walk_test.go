@@ -0,0 +1,107 @@
+// Copyright 2016 Marcel Gotsch. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goserv
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newWalkTestRouter() *Router {
+	router := newRouter()
+	router.Use(DummyHandlerFunc)
+	router.Get("/", DummyHandlerFunc)
+	router.Route("/users/:user_id").Name("user").Get(DummyHandlerFunc).Put(DummyHandlerFunc)
+
+	api := router.SubRouter("/api")
+	api.Post("/items", DummyHandlerFunc)
+	api.SubRouter("/v1").Delete("/items/:id", DummyHandlerFunc)
+
+	return router
+}
+
+func TestRouterWalk(t *testing.T) {
+	var routes []RouteInfo
+
+	err := newWalkTestRouter().Walk(func(info RouteInfo) error {
+		info.Router = nil
+		routes = append(routes, info)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected walk error: %v", err)
+	}
+
+	expected := []RouteInfo{
+		{Path: "/*", Pattern: "/*", Methods: methodNames, Middleware: true},
+		{Path: "/", Pattern: "/", Methods: []string{http.MethodGet}},
+		{Path: "/users/:user_id", Pattern: "/users/:user_id", Name: "user", Params: []string{"user_id"}, Methods: []string{http.MethodGet, http.MethodPut}},
+		{Path: "/api", Pattern: "/api", Methods: methodNames, Prefix: true},
+		{Path: "/api/items", Pattern: "/items", Methods: []string{http.MethodPost}},
+		{Path: "/api/v1", Pattern: "/v1", Methods: methodNames, Prefix: true},
+		{Path: "/api/v1/items/:id", Pattern: "/items/:id", Params: []string{"id"}, Methods: []string{http.MethodDelete}},
+	}
+
+	if len(routes) != len(expected) {
+		t.Fatalf("Wrong route count: %d != %d", len(routes), len(expected))
+	}
+
+	for index, info := range routes {
+		if !reflect.DeepEqual(info, expected[index]) {
+			t.Errorf("Wrong route info, expected: %+v, actual: %+v", expected[index], info)
+		}
+	}
+}
+
+func TestRouterWalkSkipRouter(t *testing.T) {
+	var paths []string
+
+	newWalkTestRouter().Walk(func(info RouteInfo) error {
+		paths = append(paths, info.Path)
+
+		if info.Path == "/api" {
+			return ErrSkipRouter
+		}
+
+		return nil
+	})
+
+	expected := []string{"/*", "/", "/users/:user_id", "/api"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Wrong paths, expected: %v, actual: %v", expected, paths)
+	}
+}
+
+func TestRouterWriteRouteTable(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := newWalkTestRouter().WriteRouteTable(&buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"METHODS  PATH               KIND        NAME",
+		"GET      /                  route",
+		"*        /*                 middleware",
+		"*        /api               prefix",
+		"POST     /api/items         route",
+		"*        /api/v1            prefix",
+		"DELETE   /api/v1/items/:id  route",
+		"GET,PUT  /users/:user_id    route       user",
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for index := range lines {
+		lines[index] = strings.TrimRight(lines[index], " ")
+	}
+
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Wrong route table, expected:\n%s\nactual:\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}